@@ -4,7 +4,11 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"strings"
+
 	"github.com/cldmnky/exsconvert/pkg/convert"
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	"github.com/cldmnky/exsconvert/pkg/xpm"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +20,9 @@ var (
 	programType         string
 	autoDetect          bool
 	samplesPath         string
+	pathMaps            []string
+	target              string
+	drumLayout          string
 	converter           convert.Convert
 )
 
@@ -29,6 +36,19 @@ var convertCmd = &cobra.Command{
 		//
 		xpmConverter := convert.NewXPM(searchPath, outputPath, layersPerInstrument, skipErrors, programType)
 
+		// Write the programs for the selected MPC
+		profile, err := xpm.LookupProfile(target)
+		if err != nil {
+			return err
+		}
+		xpmConverter.Target = profile
+
+		// Place the notes of drum kits on the pads
+		xpmConverter.Layout, err = convert.LookupDrumLayout(drumLayout)
+		if err != nil {
+			return err
+		}
+
 		// Set auto-detect if enabled
 		if autoDetect {
 			xpmConverter.AutoDetectDrums = true
@@ -40,8 +60,17 @@ var convertCmd = &cobra.Command{
 			xpmConverter.SamplesSearchPath = samplesPath
 		}
 
+		// Rewrite sample directories stored in the EXS files
+		for _, m := range pathMaps {
+			rule, err := exs.ParsePathRule(m)
+			if err != nil {
+				return err
+			}
+			xpmConverter.PathRules = append(xpmConverter.PathRules, rule)
+		}
+
 		converter = xpmConverter
-		err := converter.Convert()
+		err = converter.Convert()
 		if err != nil {
 			return err
 		}
@@ -54,8 +83,11 @@ func init() {
 	convertCmd.Flags().StringVarP(&searchPath, "search-path", "p", "", "search path for exs files (will search recursively)")
 	convertCmd.Flags().StringVarP(&outputPath, "output-path", "o", "", "output path for XPM files")
 	convertCmd.Flags().StringVarP(&samplesPath, "samples-path", "w", "", "search path for WAV samples (defaults to search-path)")
+	convertCmd.Flags().StringArrayVarP(&pathMaps, "path-map", "m", nil, "rewrite a sample directory stored in the exs files, as from=to (repeatable)")
 	convertCmd.Flags().IntVarP(&layersPerInstrument, "layers-per-instrument", "l", 4, "number of layers per instrument")
 	convertCmd.Flags().BoolVarP(&skipErrors, "skip-errors", "s", true, "skip errors")
 	convertCmd.Flags().StringVarP(&programType, "program-type", "t", "", "program type: Keygroup or Drum (leave empty to auto-detect)")
+	convertCmd.Flags().StringVar(&target, "target", xpm.DefaultTarget, "MPC to write programs for: "+strings.Join(xpm.ProfileNames(), ", "))
+	convertCmd.Flags().StringVar(&drumLayout, "layout", convert.DefaultDrumLayout, "pad layout of drum programs: "+strings.Join(convert.DrumLayoutNames(), ", ")+", or a layout file")
 	convertCmd.Flags().BoolVarP(&autoDetect, "auto-detect", "a", false, "auto-detect drum programs (overrides -t)")
 }
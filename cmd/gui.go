@@ -16,6 +16,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/cldmnky/exsconvert/pkg/convert"
+	"github.com/cldmnky/exsconvert/pkg/xpm"
 )
 
 var guiCmd = &cobra.Command{
@@ -37,6 +38,8 @@ type exsconvertGUI struct {
 	outputDirEntry      *widget.Entry
 	samplesPathEntry    *widget.Entry
 	layersEntry         *widget.Entry
+	targetSelect        *widget.Select
+	layoutSelect        *widget.Select
 	autoDetectCheck     *widget.Check
 	skipErrorsCheck     *widget.Check
 	statusLabel         *widget.Label
@@ -109,6 +112,26 @@ func (g *exsconvertGUI) setupUI() {
 	g.layersEntry.SetText("1")
 	g.layersEntry.SetPlaceHolder("1")
 
+	targetLabel := widget.NewLabel("Target MPC:")
+	targets := make([]string, len(xpm.Profiles))
+	for i, p := range xpm.Profiles {
+		targets[i] = p.Description
+	}
+	g.targetSelect = widget.NewSelect(targets, nil)
+	g.targetSelect.SetSelected(xpm.DefaultProfile().Description)
+
+	layoutLabel := widget.NewLabel("Drum Pad Layout:")
+	layouts := make([]string, len(convert.DrumLayouts))
+	selected := 0
+	for i, l := range convert.DrumLayouts {
+		layouts[i] = l.Description
+		if l.Name == convert.DefaultDrumLayout {
+			selected = i
+		}
+	}
+	g.layoutSelect = widget.NewSelect(layouts, nil)
+	g.layoutSelect.SetSelectedIndex(selected)
+
 	g.autoDetectCheck = widget.NewCheck("Auto-detect drum programs", nil)
 	g.autoDetectCheck.SetChecked(true)
 
@@ -117,6 +140,8 @@ func (g *exsconvertGUI) setupUI() {
 
 	optionsGrid := container.New(layout.NewFormLayout(),
 		layersLabel, g.layersEntry,
+		targetLabel, g.targetSelect,
+		layoutLabel, g.layoutSelect,
 		widget.NewLabel(""), g.autoDetectCheck,
 		widget.NewLabel(""), g.skipErrorsCheck,
 	)
@@ -277,6 +302,16 @@ func (g *exsconvertGUI) performConversion() {
 		"", // Empty program type - will be auto-detected
 	)
 
+	// Write the programs for the selected MPC
+	if i := g.targetSelect.SelectedIndex(); i >= 0 {
+		xpmConverter.Target = &xpm.Profiles[i]
+	}
+
+	// Place the notes of drum kits on the pads
+	if i := g.layoutSelect.SelectedIndex(); i >= 0 {
+		xpmConverter.Layout = &convert.DrumLayouts[i]
+	}
+
 	// Set auto-detect mode
 	xpmConverter.AutoDetectDrums = g.autoDetectCheck.Checked
 
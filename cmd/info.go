@@ -79,9 +79,9 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	// Display sequences if any
-	if len(exsFile.Sequences) > 0 {
-		printSequencesInfo(exsFile)
+	// Display round robin sets if any
+	if sets, findings := exsFile.RoundRobinSets(); len(sets) > 0 || len(findings) > 0 {
+		printRoundRobinInfo(sets, findings)
 		fmt.Println()
 	}
 
@@ -202,8 +202,10 @@ func printGroupsInfo(exsFile *exs.EXS) {
 			fmt.Printf("    Key Range:    %d-%d\n", group.KeyLow, group.KeyHigh)
 			fmt.Printf("    Vel Range:    %d-%d\n", group.VelLow, group.VelHigh)
 			fmt.Printf("    Polyphony:    %d\n", group.Polyphony)
+			fmt.Printf("    Exclusive:    %d\n", group.Exclusive)
 			fmt.Printf("    SelectGroup:  %d\n", group.SelectGroup)
 			fmt.Printf("    SelectNumber: %d\n", group.SelectNumber)
+			fmt.Printf("    Selector:     %s\n", group.Selector)
 			if group.SelectGroup >= 0 {
 				fmt.Printf("    ⚡ Round Robin Enabled\n")
 			}
@@ -216,6 +218,9 @@ func printGroupsInfo(exsFile *exs.EXS) {
 			if group.SelectGroup >= 0 {
 				rrIndicator = " [RR]"
 			}
+			if group.Selector.Active() {
+				rrIndicator += " [" + group.Selector.String() + "]"
+			}
 			fmt.Printf("    %2d. %-25s  ID: %3d  Vol: %4d  Keys: %3d-%-3d  SelectGrp: %3d%s\n",
 				i+1,
 				truncateString(group.Name, 25),
@@ -274,33 +279,72 @@ func printSamplesInfo(exsFile *exs.EXS) {
 func printParamsInfo(exsFile *exs.EXS) {
 	params := exsFile.Params
 	fmt.Println("═══ Global Parameters ═══")
-	fmt.Printf("  Output Volume:    %d dB\n", params.OutputVolume)
-	fmt.Printf("  Pitch Bend Up:    %d cents\n", params.PitchBendUp)
-	fmt.Printf("  Pitch Bend Down:  %d cents\n", params.PitchBendDown)
+	fmt.Printf("  Output Volume:    %.1f dB\n", params.OutputVolumeDB())
+	bendUp, bendDown := params.PitchBendSemitones()
+	fmt.Printf("  Pitch Bend Up:    %d semitones\n", bendUp)
+	fmt.Printf("  Pitch Bend Down:  %d semitones\n", bendDown)
+	fmt.Printf("  Transpose:        %d semitones\n", params.Transpose)
+	fmt.Printf("  Tune:             %d semitones %+d cents\n", params.CoarseTune, params.FineTune)
 	fmt.Printf("  Mono Mode:        %d\n", params.MonoMode)
 	fmt.Printf("  Voices:           %d\n", params.Voices)
 	fmt.Printf("  Unison:           %v\n", params.Unison)
+	fmt.Printf("  Glide:            %.3fs\n", params.GlideSeconds())
 	fmt.Println()
 	fmt.Println("  Filter:")
 	fmt.Printf("    Filter On:        %v\n", params.FilterOn)
 	fmt.Printf("    Filter Type:      %d\n", params.FilterType)
-	fmt.Printf("    Filter Cutoff:    %d\n", params.FilterCutoff)
-	fmt.Printf("    Filter Resonance: %d\n", params.FilterResonance)
-	fmt.Printf("    Filter Drive:     %d\n", params.FilterDrive)
+	fmt.Printf("    Filter Cutoff:    %.1f%%\n", params.FilterCutoffNormalized()*100)
+	fmt.Printf("    Filter Resonance: %.1f%%\n", params.FilterResonanceNormalized()*100)
+	fmt.Printf("    Filter Drive:     %.1f%%\n", params.FilterDriveNormalized()*100)
 	fmt.Println()
 	fmt.Println("  Envelopes:")
-	fmt.Printf("    Env1 (Filter): A=%d D=%d S=%d R=%d\n",
-		params.Env1Attack, params.Env1Decay, params.Env1Sustain, params.Env1Release)
-	fmt.Printf("    Env2 (Volume): A=%d D=%d S=%d R=%d\n",
-		params.Env2Attack, params.Env2Decay, params.Env2Sustain, params.Env2Release)
+	fmt.Printf("    Env1 (Filter): A=%.3fs D=%.3fs S=%.0f%% R=%.3fs\n",
+		params.Env1AttackSeconds(), params.Env1DecaySeconds(), params.Env1SustainLevel()*100, params.Env1ReleaseSeconds())
+	fmt.Printf("    Env2 (Volume): A=%.3fs D=%.3fs S=%.0f%% R=%.3fs\n",
+		params.Env2AttackSeconds(), params.Env2DecaySeconds(), params.Env2SustainLevel()*100, params.Env2ReleaseSeconds())
+	fmt.Println()
+	fmt.Println("  LFOs:")
+	fmt.Printf("    LFO1: %s, waveform %d, decay/delay %+.3fs\n", lfoRate(params.Lfo1Rate, params.Lfo1RateHz()), params.Lfo1Waveform, params.Lfo1DecayDelaySeconds())
+	fmt.Printf("    LFO2: %s, waveform %d\n", lfoRate(params.Lfo2Rate, params.Lfo2RateHz()), params.Lfo2Waveform)
+	fmt.Printf("    LFO3: %s\n", lfoRate(params.Lfo3Rate, params.Lfo3RateHz()))
+	fmt.Println()
+	fmt.Println("  Modulation:")
+	routings := params.ModRoutings()
+	if len(routings) == 0 {
+		fmt.Println("    No active routings")
+	}
+	for _, r := range routings {
+		fmt.Printf("    Slot %2d: %s\n", r.Slot+1, r)
+	}
+	if len(params.Unknown) > 0 {
+		fmt.Println()
+		fmt.Println("  Unmapped Parameters:")
+		for _, p := range params.Unknown {
+			table := "fixed"
+			if p.Extended {
+				table = "extended"
+			}
+			fmt.Printf("    Key %3d (%s): %d\n", p.Key, table, p.Value)
+		}
+	}
+	if len(exsFile.Extra) > 0 {
+		fmt.Println()
+		fmt.Println("  Other Chunks:")
+		for _, chunk := range exsFile.Extra {
+			fmt.Printf("    Type 0x%02X at offset %d: %d bytes\n", chunk.Type, chunk.Offset, len(chunk.Data))
+		}
+	}
 }
 
-func printSequencesInfo(exsFile *exs.EXS) {
-	fmt.Println("═══ Round Robin Sequences ═══")
-	fmt.Printf("  Total Sequences: %d\n", len(exsFile.Sequences))
+func printRoundRobinInfo(sets []exs.RoundRobinSet, findings []exs.Finding) {
+	fmt.Println("═══ Round Robin Sets ═══")
+	fmt.Printf("  Total Sets: %d\n", len(sets))
 	fmt.Println()
-	for i, seq := range exsFile.Sequences {
-		fmt.Printf("  Sequence %d: %v\n", i+1, seq)
+	for i, set := range sets {
+		fmt.Printf("  Set %d: groups %s\n", i+1, set)
+	}
+	for _, f := range findings {
+		fmt.Printf("  %s\n", f)
 	}
 }
 
@@ -335,3 +379,11 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// lfoRate formats a stored LFO rate, hz if it runs free.
+func lfoRate(rate int16, hz float64) string {
+	if exs.LfoSynced(rate) {
+		return fmt.Sprintf("synced, step %d", -rate)
+	}
+	return fmt.Sprintf("%.2f Hz", hz)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	"github.com/spf13/cobra"
+)
+
+var validateQuiet bool
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [path...]",
+	Short: "Check EXS files for structural problems",
+	Long: `Check EXS24 instrument files for structural problems before converting them.
+
+Each path is either an EXS file or a directory that is searched recursively.
+The command reports:
+- Files that cannot be decoded
+- Zones pointing at missing samples or groups
+- Inverted key and velocity ranges
+- Loops outside the played sample range
+- Overlapping zones in the same group (warning)
+- Zone, group and sample counts that disagree with the header
+
+The command exits non-zero if any file has an error.
+
+Examples:
+  exsconvert validate ~/Music/Sampler\ Instruments
+  exsconvert validate -q myinstrument.exs  # Only show errors`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().BoolVarP(&validateQuiet, "quiet", "q", false, "Only show errors, not warnings")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	var files []string
+	for _, path := range args {
+		found, err := findFiles(path, ".exs")
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	failed := 0
+	for _, file := range files {
+		exsFile, err := exs.NewFromFile(file)
+		if err != nil {
+			fmt.Printf("%s\n  error: %v\n", file, err)
+			failed++
+			continue
+		}
+		findings := exsFile.Validate()
+		if exs.HasErrors(findings) {
+			failed++
+		}
+		printed := false
+		for _, f := range findings {
+			if validateQuiet && f.Severity != exs.SeverityError {
+				continue
+			}
+			if !printed {
+				fmt.Println(file)
+				printed = true
+			}
+			fmt.Printf("  %s\n", f)
+		}
+	}
+
+	fmt.Printf("Checked %d files, %d with errors\n", len(files), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files have errors", failed, len(files))
+	}
+	return nil
+}
+
+// findFiles returns path if it is a file, or the files with extension ext
+// below it if it is a directory.
+func findFiles(path, ext string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.EqualFold(filepath.Ext(file), ext) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	return files, err
+}
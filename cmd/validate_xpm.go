@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateXPMQuiet  bool
+	validateXPMTarget string
+)
+
+// validateXPMCmd represents the validate-xpm command
+var validateXPMCmd = &cobra.Command{
+	Use:   "validate-xpm [path...]",
+	Short: "Check XPM programs against MPC limits",
+	Long: `Check MPC programs against the limits of the target MPC before copying them to it.
+
+Each path is either an XPM file or a directory that is searched recursively.
+The command reports:
+- Files that cannot be decoded
+- More keygroups or layers per instrument than the target plays
+- Inverted note and velocity ranges
+- Sample files missing from the program directory
+- Overlapping keygroups (warning)
+- Sample names too long for the MPC browser (warning)
+
+The command exits non-zero if any file has an error.
+
+Examples:
+  exsconvert validate-xpm ~/MPC/Programs
+  exsconvert validate-xpm --target mpc-2.9 myprogram.xpm
+  exsconvert validate-xpm -q ~/MPC/Programs  # Only show errors`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runValidateXPM,
+}
+
+func init() {
+	rootCmd.AddCommand(validateXPMCmd)
+	validateXPMCmd.Flags().BoolVarP(&validateXPMQuiet, "quiet", "q", false, "Only show errors, not warnings")
+	validateXPMCmd.Flags().StringVar(&validateXPMTarget, "target", xpm.DefaultTarget, "MPC to check programs for: "+strings.Join(xpm.ProfileNames(), ", "))
+}
+
+func runValidateXPM(cmd *cobra.Command, args []string) error {
+	target, err := xpm.LookupProfile(validateXPMTarget)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, path := range args {
+		found, err := findFiles(path, ".xpm")
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+
+	failed := 0
+	for _, file := range files {
+		program, err := xpm.Load(file)
+		if err != nil {
+			fmt.Printf("%s\n  error: %v\n", file, err)
+			failed++
+			continue
+		}
+		findings := program.Validate(filepath.Dir(file), target)
+		if xpm.HasErrors(findings) {
+			failed++
+		}
+		printed := false
+		for _, f := range findings {
+			if validateXPMQuiet && f.Severity != xpm.SeverityError {
+				continue
+			}
+			if !printed {
+				fmt.Println(file)
+				printed = true
+			}
+			fmt.Printf("  %s\n", f)
+		}
+	}
+
+	fmt.Printf("Checked %d files, %d with errors\n", len(files), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files have errors", failed, len(files))
+	}
+	return nil
+}
@@ -4,10 +4,14 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	"github.com/cldmnky/exsconvert/pkg/xpm"
 )
 
 var _ = Describe("Convert", func() {
@@ -265,30 +269,30 @@ var _ = Describe("Convert", func() {
 		//var err error
 		It("should normalize values", func() {
 			v := normalizeValue(float64(25), float64(0), float64(50))
-			Expect(v).To(Equal("0.500000"))
+			Expect(v.String()).To(Equal("0.500000"))
 		})
 
 		It("should normalize edge values", func() {
 			v := normalizeValue(float64(0), float64(0), float64(100))
-			Expect(v).To(Equal("0.000000"))
+			Expect(v.String()).To(Equal("0.000000"))
 			v = normalizeValue(float64(100), float64(0), float64(100))
-			Expect(v).To(Equal("1.000000"))
+			Expect(v.String()).To(Equal("1.000000"))
 			v = normalizeValue(float64(50), float64(0), float64(100))
-			Expect(v).To(Equal("0.500000"))
+			Expect(v.String()).To(Equal("0.500000"))
 		})
 
 		It("should convert gain to db", func() {
 			v := convertGain(float64(-96))
-			Expect(v).To(Equal("0.353000"))
+			Expect(v.String()).To(Equal("0.353000"))
 			v = convertGain(float64(12))
-			Expect(v).To(Equal("1.000000"))
+			Expect(v.String()).To(Equal("1.000000"))
 		})
 
 		It("should clamp gain values within bounds", func() {
 			v := convertGain(float64(-20)) // Below -12
-			Expect(v).To(Equal("0.353000"))
+			Expect(v.String()).To(Equal("0.353000"))
 			v = convertGain(float64(10)) // Above 6
-			Expect(v).To(Equal("1.000000"))
+			Expect(v.String()).To(Equal("1.000000"))
 		})
 
 		It("should clamp values correctly", func() {
@@ -309,27 +313,27 @@ var _ = Describe("Convert", func() {
 		It("should convert EXS volume (dB) to XPM linear gain", func() {
 			// Test unity gain (0 dB -> 1.0)
 			result := convertVolumeDbToLinear(0)
-			Expect(result).To(Equal("1.000000"))
+			Expect(result.String()).To(Equal("1.000000"))
 
 			// Test -6 dB (half power -> ~0.501)
 			result = convertVolumeDbToLinear(-6)
-			Expect(result).To(ContainSubstring("0.501"))
+			Expect(result.String()).To(ContainSubstring("0.501"))
 
 			// Test +6 dB (double power -> ~2.0)
 			result = convertVolumeDbToLinear(6)
-			Expect(result).To(ContainSubstring("1.995"))
+			Expect(result.String()).To(ContainSubstring("1.995"))
 
 			// Test -12 dB (quarter power -> ~0.251)
 			result = convertVolumeDbToLinear(-12)
-			Expect(result).To(ContainSubstring("0.251"))
+			Expect(result.String()).To(ContainSubstring("0.251"))
 
 			// Test +12 dB (max boost -> ~3.981)
 			result = convertVolumeDbToLinear(12)
-			Expect(result).To(ContainSubstring("3.981"))
+			Expect(result.String()).To(ContainSubstring("3.981"))
 
 			// Test -60 dB (near silence -> ~0.001)
 			result = convertVolumeDbToLinear(-60)
-			Expect(result).To(ContainSubstring("0.001"))
+			Expect(result.String()).To(ContainSubstring("0.001"))
 		})
 
 		It("should clamp volume values outside valid range", func() {
@@ -347,23 +351,23 @@ var _ = Describe("Convert", func() {
 		It("should convert EXS pan to XPM normalized range", func() {
 			// Test center (0 -> 0.5)
 			result := convertPanToNormalized(0)
-			Expect(result).To(ContainSubstring("0.503"))
+			Expect(result.String()).To(ContainSubstring("0.503"))
 
 			// Test hard left (-64 -> 0.0)
 			result = convertPanToNormalized(-64)
-			Expect(result).To(Equal("0.000000"))
+			Expect(result.String()).To(Equal("0.000000"))
 
 			// Test hard right (+63 -> 1.0, as (63+64)/127 = 127/127 = 1.0)
 			result = convertPanToNormalized(63)
-			Expect(result).To(Equal("1.000000"))
+			Expect(result.String()).To(Equal("1.000000"))
 
 			// Test left quarter (-32 -> 0.25)
 			result = convertPanToNormalized(-32)
-			Expect(result).To(ContainSubstring("0.251"))
+			Expect(result.String()).To(ContainSubstring("0.251"))
 
 			// Test right quarter (+32 -> 0.75)
 			result = convertPanToNormalized(32)
-			Expect(result).To(ContainSubstring("0.755"))
+			Expect(result.String()).To(ContainSubstring("0.755"))
 		})
 
 		It("should clamp pan values outside valid range", func() {
@@ -381,31 +385,22 @@ var _ = Describe("Convert", func() {
 
 	Context("Phase 2: Zone Scale, Output Routing, One-shot Mode", func() {
 		It("should convert EXS scale to XPM KeyTrack", func() {
-			// Test full key tracking (100 -> 1.0)
+			// Test full key tracking (100 -> True)
 			result := convertScaleToKeyTrack(100)
-			Expect(result).To(Equal("1.000000"))
+			Expect(result.String()).To(Equal("True"))
 
-			// Test no key tracking (0 -> 0.0)
+			// Test no key tracking (0 -> False)
 			result = convertScaleToKeyTrack(0)
-			Expect(result).To(Equal("0.000000"))
+			Expect(result.String()).To(Equal("False"))
 
-			// Test half key tracking (50 -> 0.5)
+			// Test partial key tracking (50 -> True, the MPC tracks fully or not at all)
 			result = convertScaleToKeyTrack(50)
-			Expect(result).To(Equal("0.500000"))
-
-			// Test 75% key tracking (75 -> 0.75)
-			result = convertScaleToKeyTrack(75)
-			Expect(result).To(Equal("0.750000"))
+			Expect(result.String()).To(Equal("True"))
 		})
 
-		It("should clamp scale values outside valid range", func() {
-			// Below minimum should clamp to 0
+		It("should not track the key for negative scale values", func() {
 			result := convertScaleToKeyTrack(-50)
-			Expect(result).To(Equal("0.000000"))
-
-			// Above maximum should clamp to 100
-			result = convertScaleToKeyTrack(150)
-			Expect(result).To(Equal("1.000000"))
+			Expect(result.String()).To(Equal("False"))
 		})
 
 		It("should convert EXS output to XPM AudioRoute integer", func() {
@@ -435,6 +430,325 @@ var _ = Describe("Convert", func() {
 		})
 	})
 
+	Context("Filter type", func() {
+		It("should map the EXS filters to the MPC ones", func() {
+			for exsType, want := range map[int16]int{
+				exs.FilterLP24: xpm.FilterLow4,
+				exs.FilterLP18: xpm.FilterLow4,
+				exs.FilterLP12: xpm.FilterLow2,
+				exs.FilterLP6:  xpm.FilterLow1,
+				exs.FilterHP12: xpm.FilterHigh2,
+				exs.FilterBP12: xpm.FilterBand2,
+			} {
+				filterType, ok := convertFilterType(&exs.Params{FilterOn: true, FilterType: exsType})
+				Expect(ok).To(BeTrue())
+				Expect(filterType).To(Equal(want), "EXS filter %d", exsType)
+			}
+		})
+
+		It("should turn fat low passes into the Classic filter", func() {
+			filterType, _ := convertFilterType(&exs.Params{FilterOn: true, FilterFat: true, FilterType: exs.FilterLP12})
+			Expect(filterType).To(Equal(xpm.FilterClassic))
+			filterType, _ = convertFilterType(&exs.Params{FilterOn: true, FilterFat: true, FilterType: exs.FilterHP12})
+			Expect(filterType).To(Equal(xpm.FilterHigh2))
+		})
+
+		It("should switch the filter off only when the EXS filter is stored off", func() {
+			filterType, ok := convertFilterType(&exs.Params{Keys: []uint8{44}, FilterType: exs.FilterLP24})
+			Expect(ok).To(BeTrue())
+			Expect(filterType).To(Equal(xpm.FilterOff))
+			_, ok = convertFilterType(&exs.Params{Keys: []uint8{46}, FilterType: exs.FilterLP24})
+			Expect(ok).To(BeFalse())
+		})
+
+		It("should write the filter and key tracking of converted instruments", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			outputDir := GinkgoT().TempDir()
+			converter := NewXPM(testDataPath, outputDir, 1, true, "Keygroup")
+			for _, name := range []string{"Analog Strings - Kawaii Dreams From Mars", "LegacyPulsar"} {
+				Expect(converter.ConvertFile(filepath.Join(testDataPath, name+".exs"))).To(Succeed())
+			}
+
+			// Analog Strings has a fat 24 dB low pass, LegacyPulsar a 12 dB one
+			for name, want := range map[string]int{"Analog Strings - Kawaii Dreams From Mars": xpm.FilterClassic, "LegacyPulsar": xpm.FilterLow2} {
+				written, err := filepath.Glob(filepath.Join(outputDir, name, "*.xpm"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(written).To(HaveLen(1))
+				program, err := xpm.Load(written[0])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(program.Program.Instruments.Instrument).ToNot(BeEmpty())
+				for _, instrument := range program.Program.Instruments.Instrument {
+					Expect(instrument.FilterType).To(Equal(want), name)
+					Expect(instrument.FilterKeytrack.String()).To(Equal("0.000000"))
+				}
+			}
+
+			// Half key tracking
+			kit, err := exs.NewFromFile(filepath.Join(testDataPath, "LegacyPulsar.exs"))
+			Expect(err).ToNot(HaveOccurred())
+			kit.Params.FilterViaKey = 500
+			exsFile := filepath.Join(GinkgoT().TempDir(), "Tracking.exs")
+			f, err := os.Create(exsFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exs.Encode(f, kit)).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+			Expect(converter.ConvertFile(exsFile)).To(Succeed())
+			written, err := filepath.Glob(filepath.Join(outputDir, "Tracking", "*.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written).To(HaveLen(1))
+			program, err := xpm.Load(written[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(program.Program.Instruments.Instrument[0].FilterKeytrack.String()).To(Equal("0.500000"))
+		})
+	})
+
+	Context("Modulation", func() {
+		// matrix returns params decoded from a parameter table holding
+		// routings, with amounts in [-1000,1000]. Slot n is stored under the
+		// keys 173+6n on: destination, source, via, amount and amount via.
+		// As in files, the via and its amount are only stored if the routing
+		// has a via.
+		matrix := func(routings ...exs.ModRouting) *exs.Params {
+			var table exs.ExsParams
+			n := 0
+			put := func(key uint8, value int16) {
+				table.Keys[n], table.Values[n] = key, value
+				n++
+			}
+			for slot, r := range routings {
+				key := uint8(173 + 6*slot)
+				put(key, int16(r.Destination))
+				put(key+1, int16(r.Source))
+				put(key+3, int16(r.Amount))
+				if r.HasVia() {
+					put(key+2, int16(r.Via))
+					put(key+4, int16(r.AmountVia))
+				}
+			}
+			return exs.NewParamsFromExsParams(&table)
+		}
+
+		It("should map mod wheel vibrato to the LFO and the wheel", func() {
+			m := convertModulation("test", matrix(
+				exs.ModRouting{Source: exs.ModSourceLFO1, Destination: exs.ModDestPitch, Via: exs.ModSourceModWheel, Amount: 0, AmountVia: 400},
+			))
+			Expect(m.lfoPitch).To(BeNumerically("~", 0.4))
+			Expect(m.wheelToLfo).To(BeNumerically("~", 1))
+
+			// A depth the wheel only adds to
+			m = convertModulation("test", matrix(
+				exs.ModRouting{Source: exs.ModSourceLFO1, Destination: exs.ModDestPitch, Via: exs.ModSourceModWheel, Amount: 100, AmountVia: 400},
+			))
+			Expect(m.lfoPitch).To(BeNumerically("~", 0.4))
+			Expect(m.wheelToLfo).To(BeNumerically("~", 0.75))
+
+			// The mod wheel scaled by the LFO
+			m = convertModulation("test", matrix(
+				exs.ModRouting{Source: exs.ModSourceModWheel, Destination: exs.ModDestPitch, Via: exs.ModSourceLFO1, Amount: 0, AmountVia: 200},
+			))
+			Expect(m.lfoPitch).To(BeNumerically("~", 0.2))
+			Expect(m.wheelToLfo).To(BeNumerically("~", 1))
+		})
+
+		It("should map velocity and aftertouch routings", func() {
+			m := convertModulation("test", matrix(
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestFilterCutoff, Via: exs.ModSourceOff, Amount: 600},
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestPan, Via: exs.ModSourceOff, Amount: 200},
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestVolume, Via: exs.ModSourceOff, Amount: 800},
+				exs.ModRouting{Source: exs.ModSourceAftertouch, Destination: exs.ModDestFilterCutoff, Via: exs.ModSourceOff, Amount: 500},
+				exs.ModRouting{Source: exs.ModSourceLFO2, Destination: exs.ModDestFilterCutoff, Via: exs.ModSourceOff, Amount: -300},
+			))
+			Expect(m.velocityToFilter).To(BeNumerically("~", 0.6))
+			Expect(m.velocityToPan).To(BeNumerically("~", 0.2))
+			Expect(m.velocityToVolume).To(BeNumerically("~", 0.8))
+			Expect(m.afterTouchToFilter).To(BeNumerically("~", 0.5))
+			Expect(m.lfoCutoff).To(BeNumerically("~", 0.3))
+			Expect(m.wheelToLfo).To(BeZero())
+		})
+
+		It("should drop the routings the MPC cannot play", func() {
+			m := convertModulation("test", matrix(
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestFilterCutoff, Via: exs.ModSourceOff, Amount: -600},
+				exs.ModRouting{Source: exs.ModSourceLFO1, Destination: exs.ModDestEnv1Attack, Via: exs.ModSourceOff, Amount: 500},
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestPitch, Via: exs.ModSourceModWheel, Amount: 0, AmountVia: 500},
+			))
+			Expect(m).To(Equal(modulation{lfo: exs.ModSourceOff}))
+			Expect(convertModulation("test", nil)).To(Equal(modulation{lfo: exs.ModSourceOff}))
+
+			// A via stored as Pitch Bend, the zero value
+			m = convertModulation("test", matrix(
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestFilterCutoff, Via: exs.ModSourcePitchBend, Amount: 0, AmountVia: 600},
+			))
+			Expect(m.velocityToFilter).To(BeZero())
+		})
+
+		It("should read the zero values of slots without a via as no via", func() {
+			params := matrix(
+				exs.ModRouting{Source: exs.ModSourceVelocity, Destination: exs.ModDestFilterCutoff, Via: exs.ModSourceOff, Amount: 600},
+			)
+			Expect(params.Via[0]).To(BeZero())
+			Expect(params.ModRoutings()).To(HaveLen(1))
+			Expect(params.ModRoutings()[0].HasVia()).To(BeFalse())
+			Expect(convertModulation("test", params).velocityToFilter).To(BeNumerically("~", 0.6))
+		})
+
+		It("should write the modulation of converted instruments", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			outputDir := GinkgoT().TempDir()
+			converter := NewXPM(testDataPath, outputDir, 4, true, "Keygroup")
+			for _, name := range []string{"LegacyPulsar", "Big News (slow sweeps)"} {
+				Expect(converter.ConvertFile(filepath.Join(testDataPath, name+".exs"))).To(Succeed())
+			}
+			load := func(name string) *xpm.MPCVObject {
+				written, err := filepath.Glob(filepath.Join(outputDir, name, "*.xpm"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(written).To(HaveLen(1))
+				program, err := xpm.Load(written[0])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(program.Program.Instruments.Instrument).ToNot(BeEmpty())
+				return program
+			}
+
+			// LegacyPulsar has mod wheel vibrato and a filter envelope
+			program := load("LegacyPulsar")
+			Expect(program.Program.KeygroupWheelToLfo.String()).To(Equal("1.000000"))
+			for _, instrument := range program.Program.Instruments.Instrument {
+				Expect(instrument.LfoPitch.String()).To(Equal("1.000000"))
+				Expect(instrument.FilterEnvAmt.String()).To(Equal("0.846000"))
+				Expect(instrument.PitchEnvAmount.String()).To(Equal("0.500000"))
+			}
+
+			// Big News follows the velocity and the LFO with its volume
+			program = load("Big News (slow sweeps)")
+			for _, instrument := range program.Program.Instruments.Instrument {
+				Expect(instrument.VelocitySensitivity.String()).To(Equal("1.000000"))
+				Expect(instrument.LfoVolume.String()).To(Equal("1.000000"))
+			}
+		})
+	})
+
+	Context("LFO", func() {
+		It("should convert the waveform and rate of the routed LFO", func() {
+			params := &exs.Params{Lfo1Rate: 98, Lfo1Waveform: exs.LfoSawUp, Lfo2Rate: 112, Lfo2Waveform: exs.LfoRandom, Lfo3Rate: 84}
+			l := convertLFO("test", params, exs.ModSourceLFO1)
+			Expect(l.shape).To(Equal(xpm.LfoSawUp))
+			Expect(l.rate).To(Equal(4.8))
+			Expect(l.sync).To(BeZero())
+
+			l = convertLFO("test", params, exs.ModSourceLFO2)
+			Expect(l.shape).To(Equal(xpm.LfoRandom))
+			Expect(l.rate).To(BeNumerically("~", 9.6, 1e-9))
+
+			// LFO 3 has no waveform of its own
+			l = convertLFO("test", params, exs.ModSourceLFO3)
+			Expect(l.shape).To(Equal(xpm.LfoTriangle))
+			Expect(l.rate).To(BeNumerically("~", 2.4, 1e-9))
+
+			// Without routings LFO 1 is converted
+			Expect(convertLFO("test", params, exs.ModSourceOff)).To(Equal(convertLFO("test", params, exs.ModSourceLFO1)))
+			Expect(convertLFO("test", nil, exs.ModSourceOff)).To(Equal(lfo{shape: xpm.LfoTriangle}))
+		})
+
+		It("should sync tempo synced rates to a quarter note", func() {
+			// The note values of the synced EXS rates are not known
+			for _, rate := range []int16{-1, -9, -16} {
+				l := convertLFO("test", &exs.Params{Lfo1Rate: rate}, exs.ModSourceLFO1)
+				Expect(l.sync).To(Equal(7))
+				Expect(xpm.LfoSyncBeats[l.sync-1]).To(Equal(1.0))
+				Expect(l.rate).To(BeZero())
+			}
+		})
+
+		It("should fade LFO 1 in after its delay", func() {
+			l := convertLFO("test", &exs.Params{Lfo1Rate: 98, Lfo1DecayDelay: 1500}, exs.ModSourceLFO1)
+			Expect(l.fadeIn).To(Equal(1.5))
+			Expect(l.delay).To(BeZero())
+			Expect(l.attack).To(BeZero())
+			var instrument xpm.Instrument
+			l.apply(&instrument)
+			Expect(instrument.LFO.FadeIn).To(Equal(formatEnvTime(1.5)))
+			Expect(instrument.LFO.Delay).To(Equal(formatEnvTime(0)))
+			Expect(instrument.LFO.Attack).To(Equal(formatEnvTime(0)))
+			l = convertLFO("test", &exs.Params{Lfo1Rate: 98, Lfo1DecayDelay: -1500}, exs.ModSourceLFO1)
+			Expect(l.fadeIn).To(BeZero())
+		})
+
+		It("should write the LFO of converted instruments", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			outputDir := GinkgoT().TempDir()
+			converter := NewXPM(testDataPath, outputDir, 4, true, "Keygroup")
+			for _, name := range []string{"LegacyPulsar", "Rave Go Up - OB From Mars"} {
+				Expect(converter.ConvertFile(filepath.Join(testDataPath, name+".exs"))).To(Succeed())
+			}
+			load := func(name string) *xpm.MPCVObject {
+				written, err := filepath.Glob(filepath.Join(outputDir, name, "*.xpm"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(written).To(HaveLen(1))
+				program, err := xpm.Load(written[0])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(program.Program.Instruments.Instrument).ToNot(BeEmpty())
+				return program
+			}
+
+			// LegacyPulsar vibrates with a triangle at about 8.2 Hz
+			for _, instrument := range load("LegacyPulsar").Program.Instruments.Instrument {
+				Expect(instrument.LFO.Type).To(Equal(xpm.LfoTriangle))
+				Expect(float64(instrument.LFO.Rate)).To(BeNumerically("~", 0.852, 0.001))
+				Expect(instrument.LFO.Sync).To(BeZero())
+			}
+			// Rave Go Up has a positive square
+			for _, instrument := range load("Rave Go Up - OB From Mars").Program.Instruments.Instrument {
+				Expect(instrument.LFO.Type).To(Equal(xpm.LfoSquare))
+			}
+		})
+	})
+
+	Context("Voice mode", func() {
+		It("should limit the polyphony of poly instruments to their voices", func() {
+			program := xpm.NewXPMKeygroup().Program
+			convertVoiceMode("test", &exs.Params{MonoMode: exs.MonoOff, Voices: 12}, &program)
+			Expect(bool(program.Mono)).To(BeFalse())
+			Expect(program.ProgramPolyphony).To(Equal(12))
+			Expect(program.PortamentoTime.String()).To(Equal("0.000000"))
+		})
+
+		It("should play mono instruments with one voice", func() {
+			program := xpm.NewXPMKeygroup().Program
+			convertVoiceMode("test", &exs.Params{MonoMode: exs.MonoOn, Voices: 16, GlideTime: 100}, &program)
+			Expect(bool(program.Mono)).To(BeTrue())
+			Expect(program.ProgramPolyphony).To(Equal(1))
+			Expect(bool(program.PortamentoLegato)).To(BeFalse())
+			Expect(program.PortamentoTime.String()).To(Equal("0.020000"))
+
+			convertVoiceMode("test", &exs.Params{MonoMode: exs.MonoLegato, GlideTime: 2500}, &program)
+			Expect(bool(program.Mono)).To(BeTrue())
+			Expect(bool(program.PortamentoLegato)).To(BeTrue())
+			Expect(program.PortamentoTime.String()).To(Equal("0.500000"))
+		})
+
+		It("should spread the glide range over the portamento time", func() {
+			Expect(portamentoTime(0)).To(BeZero())
+			Expect(float64(portamentoTime(1))).To(BeNumerically("~", 0.2))
+			Expect(float64(portamentoTime(maxGlideSeconds))).To(Equal(1.0))
+			Expect(float64(portamentoTime(9))).To(Equal(1.0))
+		})
+
+		It("should convert MC-202 bass as stored", func() {
+			// MC-202 bass stores no voice mode and no glide, and its groups
+			// play poly: nothing in the file tells it is played legato
+			testDataPath := "../../pkg/exs/testdata"
+			outputDir := GinkgoT().TempDir()
+			converter := NewXPM(testDataPath, outputDir, 4, true, "Keygroup")
+			Expect(converter.ConvertFile(filepath.Join(testDataPath, "MC-202 bass.exs"))).To(Succeed())
+			program, err := xpm.Load(filepath.Join(outputDir, "MC-202 bass", "MC-202 bass.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bool(program.Program.Mono)).To(BeFalse())
+			Expect(program.Program.ProgramPolyphony).To(Equal(64))
+			Expect(bool(program.Program.PortamentoLegato)).To(BeFalse())
+			Expect(program.Program.PortamentoTime.String()).To(Equal("0.000000"))
+		})
+	})
+
 	Context("DMX Drum Kit Conversion", func() {
 		var outputDir string
 
@@ -507,12 +821,71 @@ var _ = Describe("Convert", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			xpmString := string(xpmContent)
-			// In drum mode, LowNote and HighNote should be the same for each pad
-			// This is a characteristic of drum programs
+			// Drum instruments still write the note range the MPC writes,
+			// the pad a zone plays from is set by PadNoteMap
 			Expect(xpmString).To(ContainSubstring("<LowNote>"))
 			Expect(xpmString).To(ContainSubstring("<HighNote>"))
 		})
 
+		It("should place each zone on the pad that sends its note", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			exsFile := filepath.Join(testDataPath, "DMX From Mars - Dirty Color Kit.exs")
+			samplesDir := withSamples(exsFile)
+
+			xpmConverter := NewXPM(testDataPath, outputDir, 1, true, "Drum")
+			xpmConverter.SamplesSearchPath = samplesDir
+			Expect(xpmConverter.ConvertFile(exsFile)).To(Succeed())
+
+			program, err := xpm.Load(filepath.Join(outputDir, "DMX From Mars - Dirty Color Kit", "DMX From Mars - Dirty Color Kit.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			instruments := program.Program.Instruments.Instrument
+			Expect(instruments).To(HaveLen(128))
+
+			kit, err := exs.NewFromFile(exsFile)
+			Expect(err).ToNot(HaveOccurred())
+			used := map[int]bool{}
+			for _, zone := range kit.Zones {
+				pad, ok := program.Program.PadNoteMap.Pad(int(zone.KeyLow))
+				Expect(ok).To(BeTrue())
+				Expect(instruments[pad].Layers.Layer).ToNot(BeEmpty(), "pad %d", pad+1)
+				Expect(instruments[pad].Layers.Layer[0].SampleFile).ToNot(BeEmpty())
+				used[pad] = true
+			}
+			for pad, instrument := range instruments {
+				if !used[pad] {
+					Expect(instrument.Layers.Layer).To(BeEmpty(), "pad %d", pad+1)
+				}
+			}
+		})
+
+		It("should stack the velocity layers of a note on its pad", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			exsFile := filepath.Join(testDataPath, "Shape-DFAM-PSEQOUT.exs")
+			samplesDir := withSamples(exsFile)
+
+			xpmConverter := NewXPM(testDataPath, outputDir, 4, true, "Drum")
+			xpmConverter.SamplesSearchPath = samplesDir
+			Expect(xpmConverter.ConvertFile(exsFile)).To(Succeed())
+
+			written, err := filepath.Glob(filepath.Join(outputDir, "*", "*.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written).To(HaveLen(1))
+			program, err := xpm.Load(written[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			kit, err := exs.NewFromFile(exsFile)
+			Expect(err).ToNot(HaveOccurred())
+			zonesPerKey := map[int]int{}
+			for _, zone := range kit.Zones {
+				zonesPerKey[int(zone.KeyLow)]++
+			}
+			for key, n := range zonesPerKey {
+				pad, ok := program.Program.PadNoteMap.Pad(key)
+				Expect(ok).To(BeTrue())
+				Expect(program.Program.Instruments.Instrument[pad].Layers.Layer).To(HaveLen(min(n, xpmConverter.Target.MaxLayers)), "note %d", key)
+			}
+		})
+
 		It("should convert same drum kit as keygroup for comparison", func() {
 			// This test shows the difference between drum and keygroup conversion
 			testDataPath := "../../pkg/exs/testdata"
@@ -715,8 +1088,50 @@ var _ = Describe("Convert", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			xpmString := string(xpmContent)
-			// Should have KeygroupMasterTranspose field
-			Expect(xpmString).To(ContainSubstring("<KeygroupMasterTranspose>"))
+			// K3 Big is not transposed
+			Expect(xpmString).To(ContainSubstring("<KeygroupMasterTranspose>0.500000</KeygroupMasterTranspose>"))
+		})
+
+		It("should convert the bend range, tuning and volume of the instrument", func() {
+			program := xpm.NewXPMKeygroup().Program
+			convertTuning("test", &exs.Params{PitchBendUp: 12, PitchBendDown: exs.PitchBendLinked, Transpose: -12, CoarseTune: 7, FineTune: -20, OutputVolume: -6}, &program)
+			Expect(program.KeygroupPitchBendRange.String()).To(Equal("1.000000"))
+			Expect(program.KeygroupMasterTranspose.String()).To(Equal("0.333333"))
+			Expect(program.TuneCoarse).To(Equal(7))
+			Expect(program.TuneFine).To(Equal(-20))
+			// The volume of the new programs in pkg/xpm/testdata/Empty.xpm
+			Expect(program.Volume.String()).To(Equal("0.707946"))
+
+			// The MPC bends both ways by the larger range
+			convertTuning("test", &exs.Params{PitchBendUp: 2, PitchBendDown: 7}, &program)
+			Expect(program.KeygroupPitchBendRange).To(Equal(xpm.Normalized(7.0 / 12)))
+
+			// Decoded instruments without a bend range or volume get the
+			// EXS defaults, not the zero values
+			convertTuning("test", exs.NewParamsFromExsParams(&exs.ExsParams{Keys: [100]uint8{45}, Values: [100]int16{12}}), &program)
+			Expect(program.KeygroupPitchBendRange).To(Equal(xpm.Normalized(defaultPitchBendSemitones / maxPitchBendSemitones)))
+			Expect(program.Volume.String()).To(Equal("0.707946"))
+			Expect(program.KeygroupMasterTranspose.String()).To(Equal("0.666667"))
+
+			// 4 dB below the default is -7 dB, on the scale of the 0.421697
+			// (-7.5 dB) of pkg/xpm/testdata/example.xpm
+			convertTuning("test", &exs.Params{OutputVolume: -10}, &program)
+			Expect(program.Volume.String()).To(Equal("0.446684"))
+		})
+
+		It("should transpose and level converted instruments as in Logic", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			converter := NewXPM(testDataPath, outputDir, 4, true, "Keygroup")
+			Expect(converter.ConvertFile(filepath.Join(testDataPath, "Big News (slow sweeps).exs"))).To(Succeed())
+			program, err := xpm.Load(filepath.Join(outputDir, "Big News (slow sweeps)", "Big News (slow sweeps).xpm"))
+			Expect(err).ToNot(HaveOccurred())
+
+			// Big News plays the zones an octave up and tunes them an octave
+			// down, 4 dB below the default volume
+			Expect(program.Program.KeygroupMasterTranspose.String()).To(Equal("0.666667"))
+			Expect(program.Program.TuneCoarse).To(Equal(-12))
+			Expect(program.Program.TuneFine).To(BeZero())
+			Expect(program.Program.Volume.String()).To(Equal("0.446684"))
 		})
 
 		It("should handle instruments without round robin correctly", func() {
@@ -756,4 +1171,203 @@ var _ = Describe("Convert", func() {
 			Expect(len(xpmContent)).To(BeNumerically(">", 0))
 		})
 	})
+
+	Context("Target profiles", func() {
+		var outputDir string
+
+		BeforeEach(func() {
+			outputDir = GinkgoT().TempDir()
+		})
+
+		It("should write the header and pads element of the target", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			converter := NewXPM(testDataPath, outputDir, 4, true, "Keygroup")
+			target, err := xpm.LookupProfile("mpc-2.9")
+			Expect(err).ToNot(HaveOccurred())
+			converter.Target = target
+			Expect(converter.ConvertFile(filepath.Join(testDataPath, "MC-202 bass.exs"))).To(Succeed())
+
+			program, err := xpm.Load(filepath.Join(outputDir, "MC-202 bass", "MC-202 bass.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(program.Version.ApplicationVersion).To(Equal("2.9.1.2"))
+			Expect(program.Program.ProgramPads.XMLName.Local).To(Equal(xpm.ProgramPads))
+		})
+
+		It("should limit the layers per keygroup to the target", func() {
+			testDataPath := "../../pkg/exs/testdata"
+			converter := NewXPM(testDataPath, outputDir, 16, true, "Keygroup")
+			Expect(converter.ConvertFile(filepath.Join(testDataPath, "Big News (slow sweeps).exs"))).To(Succeed())
+
+			written, err := filepath.Glob(filepath.Join(outputDir, "*", "*.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written).To(HaveLen(1))
+			program, err := xpm.Load(written[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(program.Version).To(Equal(xpm.DefaultProfile().Version))
+			for _, instrument := range program.Program.Instruments.Instrument {
+				Expect(len(instrument.Layers.Layer)).To(BeNumerically("<=", 4))
+			}
+		})
+	})
+
+	Context("Choke groups", func() {
+		testDataPath := "../../pkg/exs/testdata"
+		dmx := filepath.Join(testDataPath, "DMX From Mars - Dirty Color Kit.exs")
+
+		// convertDrums converts exsFile as a drum program and returns it with
+		// the instrument of the pad each note plays from.
+		convertDrums := func(exsFile string) (*xpm.MPCVObject, func(note int) *xpm.Instrument) {
+			outputDir := GinkgoT().TempDir()
+			xpmConverter := NewXPM(testDataPath, outputDir, 1, true, "Drum")
+			xpmConverter.SamplesSearchPath = withSamples(dmx)
+			Expect(xpmConverter.ConvertFile(exsFile)).To(Succeed())
+
+			written, err := filepath.Glob(filepath.Join(outputDir, "*", "*.xpm"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(written).To(HaveLen(1))
+			program, err := xpm.Load(written[0])
+			Expect(err).ToNot(HaveOccurred())
+			return program, func(note int) *xpm.Instrument {
+				pad, ok := program.Program.PadNoteMap.Pad(note)
+				Expect(ok).To(BeTrue())
+				return &program.Program.Instruments.Instrument[pad]
+			}
+		}
+
+		It("should number the groups whose voices cut each other", func() {
+			kit, err := exs.NewFromFile(dmx)
+			Expect(err).ToNot(HaveOccurred())
+			groupMap := make(map[uint32]*exs.Group)
+			for _, g := range kit.GetGroups() {
+				groupMap[g.ID] = g
+			}
+			// Hi Hat, Kick and Hi Hat 2 are mono, Snare & Clap and Toms are not
+			Expect(muteGroups(kit, groupMap)).To(Equal(map[uint32]int{0: 1, 1: 2, 4: 3}))
+		})
+
+		It("should choke open hi-hats with the closed ones", func() {
+			program, pad := convertDrums(dmx)
+
+			closed, accent, open := pad(42), pad(44), pad(46)
+			Expect(open.MuteGroup).ToNot(BeZero())
+			Expect(closed.MuteGroup).To(Equal(open.MuteGroup))
+			Expect(accent.MuteGroup).To(Equal(open.MuteGroup))
+			Expect(pad(47).MuteGroup).ToNot(Equal(open.MuteGroup))
+			Expect(bool(open.Mono)).To(BeTrue())
+			Expect(open.Polyphony).To(Equal(1))
+
+			number := func(instrument *xpm.Instrument) int {
+				n, err := strconv.Atoi(instrument.Number)
+				Expect(err).ToNot(HaveOccurred())
+				return n
+			}
+			Expect([]int{open.MuteTarget1, open.MuteTarget2, open.MuteTarget3, open.MuteTarget4}).To(ConsistOf(number(closed), number(accent), 0, 0))
+			Expect([]int{closed.MuteTarget1, closed.MuteTarget2}).To(ContainElement(number(open)))
+			Expect(program.Program.PadGroupMap.PadGroup[number(open)-1].Group).To(Equal(open.MuteGroup))
+
+			// The cymbals are in no group and ring on
+			ride := pad(49)
+			Expect(ride.MuteGroup).To(BeZero())
+			Expect(ride.MuteTarget1).To(BeZero())
+			Expect(bool(ride.Mono)).To(BeFalse())
+			Expect(program.Program.PadGroupMap.PadGroup[number(ride)-1].Group).To(BeZero())
+		})
+
+		It("should share a mute group between groups of one exclusive class", func() {
+			kit, err := exs.NewFromFile(dmx)
+			Expect(err).ToNot(HaveOccurred())
+			for _, g := range kit.Groups {
+				switch g.Name {
+				case "Kick":
+					g.Exclusive = 3
+				case "Snare & Clap":
+					g.Exclusive = 3
+					g.Polyphony = 4
+				}
+			}
+			exsFile := filepath.Join(GinkgoT().TempDir(), "Exclusive Kit.exs")
+			f, err := os.Create(exsFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exs.Encode(f, kit)).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			_, pad := convertDrums(exsFile)
+			snare := pad(37)
+			Expect(snare.MuteGroup).ToNot(BeZero())
+			Expect(snare.MuteGroup).ToNot(Equal(pad(42).MuteGroup))
+			Expect(pad(41).MuteGroup).To(Equal(snare.MuteGroup))
+			Expect(pad(36).MuteGroup).To(Equal(snare.MuteGroup))
+			Expect(snare.Polyphony).To(Equal(4))
+			Expect(bool(snare.Mono)).To(BeFalse())
+		})
+
+		It("should keep the settings of the first group on a shared pad", func() {
+			kit, err := exs.NewFromFile(dmx)
+			Expect(err).ToNot(HaveOccurred())
+			// Move a snare onto the note of a kick, from a louder group
+			for _, g := range kit.Groups {
+				if g.Name == "Snare & Clap" {
+					g.Volume = 6
+				}
+			}
+			for _, zone := range kit.Zones {
+				if zone.Name == "Zone #8" {
+					zone.KeyLow, zone.KeyHigh, zone.Key = 38, 38, 38
+				}
+			}
+			exsFile := filepath.Join(GinkgoT().TempDir(), "Shared Pad Kit.exs")
+			f, err := os.Create(exsFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exs.Encode(f, kit)).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			// Kick comes first in the file, the snare plays on its pad
+			_, pad := convertDrums(exsFile)
+			kick, shared := pad(36), pad(38)
+			Expect(shared.Layers.Layer).To(HaveLen(2))
+			Expect(shared.MuteGroup).ToNot(BeZero())
+			Expect(shared.MuteGroup).To(Equal(kick.MuteGroup))
+			Expect(shared.Volume).To(Equal(kick.Volume))
+			Expect(bool(shared.Mono)).To(BeTrue())
+			Expect(shared.Volume).ToNot(Equal(pad(37).Volume))
+		})
+
+		It("should play one-shot kits one-shot on the default target", func() {
+			kit, err := exs.NewFromFile(dmx)
+			Expect(err).ToNot(HaveOccurred())
+			for _, zone := range kit.Zones {
+				zone.OneShot = true
+			}
+			exsFile := filepath.Join(GinkgoT().TempDir(), "One Shot Kit.exs")
+			f, err := os.Create(exsFile)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exs.Encode(f, kit)).To(Succeed())
+			Expect(f.Close()).To(Succeed())
+
+			// MPC OS 2.11 reads no OneShot, the trigger mode carries it
+			program, _ := convertDrums(exsFile)
+			Expect(program.Version).To(Equal(xpm.DefaultProfile().Version))
+			played := 0
+			for _, instrument := range program.Program.Instruments.Instrument {
+				if len(instrument.Layers.Layer) > 0 {
+					Expect(instrument.TriggerMode).To(BeZero())
+					Expect(bool(instrument.OneShot)).To(BeFalse())
+					played++
+				}
+			}
+			Expect(played).ToNot(BeZero())
+		})
+	})
 })
+
+// withSamples writes an empty file for every sample of the EXS file to a
+// new directory and returns it, as the test data has no audio.
+func withSamples(exsFile string) string {
+	instrument, err := exs.NewFromFile(exsFile)
+	Expect(err).ToNot(HaveOccurred())
+	dir := GinkgoT().TempDir()
+	for _, sample := range instrument.Samples {
+		Expect(os.WriteFile(filepath.Join(dir, sample.FileName), nil, 0644)).To(Succeed())
+	}
+	return dir
+}
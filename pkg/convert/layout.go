@@ -0,0 +1,369 @@
+package convert
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+)
+
+// ============================================================================
+// Drum Layouts
+// ============================================================================
+
+const (
+	padCount = 128 // pads of a drum program
+	bankSize = 16  // pads of a bank, A01 to A16 being bank A
+)
+
+// DrumSound is a note of a drum kit and the sample it plays.
+type DrumSound struct {
+	Note   int
+	Sample string
+}
+
+// DrumLayout places the sounds of a drum kit on the pads of a drum program.
+type DrumLayout struct {
+	Name        string // value of convert --layout
+	Description string // shown in the GUI
+	// place returns the zero based pad of the notes it places. Sounds it
+	// leaves out, or puts on a pad already taken, go to the first free pads.
+	place func(sounds []DrumSound) map[int]int
+	// fixed keeps the pads where place puts them, as layout files do, even
+	// if that leaves pad bank A empty.
+	fixed bool
+}
+
+// DefaultDrumLayout is the layout used when none is selected.
+const DefaultDrumLayout = "gm"
+
+// DrumLayouts lists the built-in layouts.
+var DrumLayouts = []DrumLayout{
+	{
+		Name:        "gm",
+		Description: "General MIDI kit on pad bank A",
+		place:       placeGM,
+	},
+	{
+		Name:        "chromatic",
+		Description: "Chromatic from C1 on pad A01",
+		place:       placeChromatic,
+	},
+	{
+		Name:        "category",
+		Description: "Sorted by sample name: kicks, snares, hats, toms, cymbals, percussion",
+		place:       placeByCategory,
+	},
+}
+
+// LookupDrumLayout returns the built-in layout called name or, if there is
+// none, the layout file at path name.
+func LookupDrumLayout(name string) (*DrumLayout, error) {
+	for i := range DrumLayouts {
+		if strings.EqualFold(DrumLayouts[i].Name, name) {
+			return &DrumLayouts[i], nil
+		}
+	}
+	if _, err := os.Stat(name); err != nil {
+		return nil, fmt.Errorf("unknown layout %q, want one of %s or a layout file", name, strings.Join(DrumLayoutNames(), ", "))
+	}
+	return LoadDrumLayout(name)
+}
+
+// defaultDrumLayout returns the DefaultDrumLayout layout, the first of
+// DrumLayouts.
+func defaultDrumLayout() *DrumLayout {
+	return &DrumLayouts[0]
+}
+
+// DrumLayoutNames returns the names of DrumLayouts in order.
+func DrumLayoutNames() []string {
+	names := make([]string, len(DrumLayouts))
+	for i, l := range DrumLayouts {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// LoadDrumLayout reads a layout file. Each line holds a note, as a number
+// or a name with C3 being 60, and the pad it goes to, as a number from 1 to
+// 128 or a bank and pad such as A01:
+//
+//	# kick, snare and hats on the bottom row
+//	36  A01
+//	D1  A02
+//	42  3
+//
+// Text after # is a comment. Notes the file does not name go to the first
+// free pads, the ones it names stay on their pads.
+func LoadDrumLayout(path string) (*DrumLayout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pads := make(map[int]int)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want a note and a pad, got %q", path, line, strings.TrimSpace(text))
+		}
+		note, err := ParseNote(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		pad, err := ParsePad(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if _, ok := pads[note]; ok {
+			return nil, fmt.Errorf("%s:%d: note %s placed twice", path, line, fields[0])
+		}
+		pads[note] = pad
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &DrumLayout{
+		Name:        filepath.Base(path),
+		Description: "Layout from " + path,
+		place: func([]DrumSound) map[int]int {
+			return pads
+		},
+		fixed: true,
+	}, nil
+}
+
+// ParseNote reads a MIDI note number, or a note name such as C1, F#2 or
+// Bb-1, with C3 being 60 as in Logic and on the MPC.
+func ParseNote(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 127 {
+			return 0, fmt.Errorf("note %d out of range 0-127", n)
+		}
+		return n, nil
+	}
+	names := map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+	if s == "" {
+		return 0, fmt.Errorf("empty note")
+	}
+	pitch, ok := names[byte(unicode.ToUpper(rune(s[0])))]
+	if !ok {
+		return 0, fmt.Errorf("invalid note %q", s)
+	}
+	rest := s[1:]
+	switch {
+	case strings.HasPrefix(rest, "#"):
+		pitch++
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "b"):
+		pitch--
+		rest = rest[1:]
+	}
+	octave, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid note %q", s)
+	}
+	n := (octave+2)*12 + pitch
+	if n < 0 || n > 127 {
+		return 0, fmt.Errorf("note %q out of range C-2 to G8", s)
+	}
+	return n, nil
+}
+
+// ParsePad reads a pad number from 1 to 128, or a bank and pad such as A01
+// or h16, and returns the zero based pad.
+func ParsePad(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > padCount {
+			return 0, fmt.Errorf("pad %d out of range 1-%d", n, padCount)
+		}
+		return n - 1, nil
+	}
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid pad %q", s)
+	}
+	bank := int(unicode.ToUpper(rune(s[0])) - 'A')
+	n, err := strconv.Atoi(s[1:])
+	if err != nil || bank < 0 || bank >= padCount/bankSize || n < 1 || n > bankSize {
+		return 0, fmt.Errorf("invalid pad %q, want A01 to H16", s)
+	}
+	return bank*bankSize + n - 1, nil
+}
+
+// Place returns the zero based pad of the note of each sound. Sounds the
+// layout leaves out take the first free pads. A built-in layout that leaves
+// bank A empty moves down as a whole to start on A01, so every kit plays
+// from the first 16 pads with the layout kept.
+func (l *DrumLayout) Place(sounds []DrumSound) map[int]int {
+	sounds = uniqueSounds(sounds)
+	placed := l.place(sounds)
+
+	pads := make(map[int]int, len(sounds))
+	var used [padCount]bool
+	var rest []int
+	for _, sound := range sounds {
+		pad, ok := placed[sound.Note]
+		if !ok || pad < 0 || pad >= padCount || used[pad] {
+			rest = append(rest, sound.Note)
+			continue
+		}
+		pads[sound.Note] = pad
+		used[pad] = true
+	}
+	free := 0
+	for _, note := range rest {
+		for used[free] {
+			free++
+		}
+		pads[note] = free
+		used[free] = true
+	}
+
+	if l.fixed || len(pads) == 0 {
+		return pads
+	}
+	lowest := padCount
+	for _, pad := range pads {
+		lowest = min(lowest, pad)
+	}
+	if lowest >= bankSize {
+		for note := range pads {
+			pads[note] -= lowest
+		}
+	}
+	return pads
+}
+
+// uniqueSounds returns sounds by ascending note, the first sound of each
+// note only.
+func uniqueSounds(sounds []DrumSound) []DrumSound {
+	seen := make(map[int]bool, len(sounds))
+	unique := make([]DrumSound, 0, len(sounds))
+	for _, sound := range sounds {
+		if sound.Note < 0 || sound.Note > 127 || seen[sound.Note] {
+			continue
+		}
+		seen[sound.Note] = true
+		unique = append(unique, sound)
+	}
+	sort.SliceStable(unique, func(i, j int) bool { return unique[i].Note < unique[j].Note })
+	return unique
+}
+
+// gmPads puts the General MIDI drum notes on bank A, bottom row first:
+// kicks and snares with the hats next to them, then toms, then cymbals.
+var gmPads = map[int]int{
+	36: 0, 38: 1, 42: 2, 46: 3, // kick, snare, closed and open hat
+	35: 4, 40: 5, 44: 6, 39: 7, // kick 2, snare 2, pedal hat, clap
+	41: 8, 45: 9, 48: 10, 50: 11, // low floor, low, high mid and high tom
+	37: 12, 49: 13, 51: 14, 56: 15, // side stick, crash, ride, cowbell
+}
+
+func placeGM(sounds []DrumSound) map[int]int {
+	return gmPads
+}
+
+func placeChromatic(sounds []DrumSound) map[int]int {
+	const c1 = 36
+	pads := make(map[int]int, len(sounds))
+	for _, sound := range sounds {
+		if sound.Note >= c1 {
+			pads[sound.Note] = sound.Note - c1
+		}
+	}
+	return pads
+}
+
+func placeByCategory(sounds []DrumSound) map[int]int {
+	sorted := append([]DrumSound(nil), sounds...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sampleCategory(sorted[i].Sample) < sampleCategory(sorted[j].Sample)
+	})
+	pads := make(map[int]int, len(sorted))
+	for pad, sound := range sorted {
+		pads[sound.Note] = pad
+	}
+	return pads
+}
+
+// Sample categories in the order the category layout places them.
+const (
+	categoryKick = iota
+	categorySnare
+	categoryClap
+	categoryRim
+	categoryClosedHat
+	categoryOpenHat
+	categoryTom
+	categoryCymbal
+	categoryPercussion
+	categoryOther
+)
+
+// categoryWords are the words of sample names that tell their category.
+// Short words must match a whole word, longer ones may start one, so
+// "Kick01" and "Hihat" match but "Bdx" does not.
+var categoryWords = []struct {
+	category int
+	words    []string
+}{
+	{categoryOpenHat, []string{"oh", "ohh", "ohat", "open"}},
+	{categoryClosedHat, []string{"ch", "chh", "hh", "hat", "hihat", "hats", "closed", "pedal"}},
+	{categoryKick, []string{"bd", "kick", "kik", "bassdrum"}},
+	{categorySnare, []string{"sd", "snr", "snare"}},
+	{categoryClap, []string{"cp", "clap", "handclap"}},
+	{categoryRim, []string{"rim", "rimshot", "stick", "sidestick"}},
+	{categoryTom, []string{"tom", "toms", "lt", "mt", "ht"}},
+	{categoryCymbal, []string{"cy", "cym", "cymbal", "crash", "ride", "splash", "china"}},
+	{categoryPercussion, []string{"perc", "conga", "bongo", "cowbell", "bell", "shaker", "shk", "tamb", "tambourine", "clave", "block", "guiro", "cabasa", "triangle", "timbale", "agogo", "maraca", "cb"}},
+}
+
+// sampleCategory returns the category of a sample by the words of its
+// name, categoryOther if none tells.
+func sampleCategory(name string) int {
+	words := strings.FieldsFunc(strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, c := range categoryWords {
+		for _, word := range words {
+			for _, w := range c.words {
+				if word == w || len(w) > 3 && strings.HasPrefix(word, w) {
+					return c.category
+				}
+			}
+		}
+	}
+	return categoryOther
+}
+
+// drumSounds returns the note each zone of the kit plays from and the name
+// of its sample.
+func drumSounds(exsFile *exs.EXS, groupMap map[uint32]*exs.Group) []DrumSound {
+	sounds := make([]DrumSound, 0, len(exsFile.Zones))
+	for _, zone := range exsFile.Zones {
+		note := int(zone.KeyLow)
+		if g, ok := groupMap[uint32(zone.GroupIndex)]; ok && g.KeyLow != 0 && note < int(g.KeyLow) {
+			note = int(g.KeyLow)
+		}
+		sound := DrumSound{Note: note}
+		if zone.SampleIndex >= 0 && int(zone.SampleIndex) < len(exsFile.Samples) {
+			sound.Sample = exsFile.Samples[zone.SampleIndex].Name
+		}
+		sounds = append(sounds, sound)
+	}
+	return sounds
+}
@@ -0,0 +1,153 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+var _ = Describe("Drum layouts", func() {
+	// notes returns a sound for each note from low to high.
+	notes := func(low, high int) []DrumSound {
+		var sounds []DrumSound
+		for note := low; note <= high; note++ {
+			sounds = append(sounds, DrumSound{Note: note})
+		}
+		return sounds
+	}
+
+	layout := func(name string) *DrumLayout {
+		l, err := LookupDrumLayout(name)
+		Expect(err).ToNot(HaveOccurred())
+		return l
+	}
+
+	It("should parse notes and pads", func() {
+		for s, want := range map[string]int{"36": 36, "C1": 36, "c3": 60, "F#2": 54, "Bb-1": 22, "C-2": 0, "G8": 127} {
+			note, err := ParseNote(s)
+			Expect(err).ToNot(HaveOccurred(), s)
+			Expect(note).To(Equal(want), s)
+		}
+		for _, s := range []string{"", "128", "H2", "G#8", "C"} {
+			_, err := ParseNote(s)
+			Expect(err).To(HaveOccurred(), s)
+		}
+
+		for s, want := range map[string]int{"1": 0, "A01": 0, "a1": 0, "b16": 31, "H16": 127, "128": 127} {
+			pad, err := ParsePad(s)
+			Expect(err).ToNot(HaveOccurred(), s)
+			Expect(pad).To(Equal(want), s)
+		}
+		for _, s := range []string{"0", "129", "I01", "A17", "A"} {
+			_, err := ParsePad(s)
+			Expect(err).To(HaveOccurred(), s)
+		}
+	})
+
+	It("should put a General MIDI kit on bank A", func() {
+		Expect(defaultDrumLayout().Name).To(Equal(DefaultDrumLayout))
+		pads := layout("gm").Place(notes(35, 51))
+		Expect(pads).To(HaveLen(17))
+		Expect(pads[36]).To(Equal(0))
+		Expect(pads[38]).To(Equal(1))
+		Expect(pads[42]).To(Equal(2))
+		Expect(pads[46]).To(Equal(3))
+		inBankA := 0
+		for _, pad := range pads {
+			if pad < bankSize {
+				inBankA++
+			}
+		}
+		Expect(inBankA).To(Equal(bankSize))
+	})
+
+	It("should lay notes out chromatically from C1", func() {
+		pads := layout("chromatic").Place(notes(36, 40))
+		Expect(pads).To(Equal(map[int]int{36: 0, 37: 1, 38: 2, 39: 3, 40: 4}))
+
+		// The 909 hats start at C3, past bank A, so the layout moves down to
+		// start on A01 and stays chromatic past A16
+		pads = layout("chromatic").Place(notes(60, 79))
+		for note := 60; note <= 79; note++ {
+			Expect(pads[note]).To(Equal(note-60), "note %d", note)
+		}
+
+		// Kits reaching into bank A stay where the layout puts them
+		pads = layout("chromatic").Place([]DrumSound{{Note: 40}, {Note: 60}})
+		Expect(pads).To(Equal(map[int]int{40: 4, 60: 24}))
+	})
+
+	It("should sort sounds by sample name category", func() {
+		sounds := []DrumSound{
+			{Note: 36, Sample: "Vox Hey.wav"},
+			{Note: 37, Sample: "OH DMX 06.wav"},
+			{Note: 38, Sample: "Crash DMX 05.wav"},
+			{Note: 39, Sample: "CH DMX 06.wav"},
+			{Note: 40, Sample: "Snare 2.wav"},
+			{Note: 41, Sample: "Tamb DMX 07.wav"},
+			{Note: 42, Sample: "Kick01.wav"},
+			{Note: 43, Sample: "BD DMX Color A 24.wav"},
+			{Note: 44, Sample: "Tom Hi.wav"},
+			{Note: 45, Sample: "Clap DMX Color B 01.wav"},
+		}
+		pads := layout("category").Place(sounds)
+		order := make([]int, len(pads))
+		for note, pad := range pads {
+			order[pad] = note
+		}
+		Expect(order).To(Equal([]int{42, 43, 40, 45, 39, 37, 44, 38, 41, 36}))
+	})
+
+	It("should read layout files", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "kit.txt")
+		Expect(os.WriteFile(path, []byte("# kick and snare\n36 A02\nD1 1 # snare\n\n60 B01\n"), 0644)).To(Succeed())
+		l := layout(path)
+		Expect(l.Name).To(Equal("kit.txt"))
+
+		pads := l.Place(append(notes(36, 51), DrumSound{Note: 60}))
+		Expect(pads[36]).To(Equal(1))
+		Expect(pads[38]).To(Equal(0))
+		Expect(pads[37]).To(Equal(2))
+		Expect(pads[60]).To(Equal(16))
+
+		// The pads the file names are kept, even with bank A empty
+		pads = l.Place([]DrumSound{{Note: 36}, {Note: 60}})
+		Expect(pads).To(Equal(map[int]int{36: 1, 60: 16}))
+		pads = l.Place([]DrumSound{{Note: 60}})
+		Expect(pads).To(Equal(map[int]int{60: 16}))
+
+		Expect(os.WriteFile(path, []byte("36 A01\n37\n"), 0644)).To(Succeed())
+		_, err := LookupDrumLayout(path)
+		Expect(err).To(MatchError(ContainSubstring("kit.txt:2")))
+		_, err = LookupDrumLayout("gm-reversed")
+		Expect(err).To(MatchError(ContainSubstring("chromatic")))
+	})
+
+	It("should make converted kits play from the first pads", func() {
+		testDataPath := "../../pkg/exs/testdata"
+		exsFile := filepath.Join(testDataPath, "Hi Hat 909 Clean.exs")
+		outputDir := GinkgoT().TempDir()
+
+		xpmConverter := NewXPM(testDataPath, outputDir, 1, true, "Drum")
+		xpmConverter.SamplesSearchPath = withSamples(exsFile)
+		xpmConverter.Layout = layout("chromatic")
+		Expect(xpmConverter.ConvertFile(exsFile)).To(Succeed())
+
+		program, err := xpm.Load(filepath.Join(outputDir, "Hi Hat 909 Clean", "Hi Hat 909 Clean.xpm"))
+		Expect(err).ToNot(HaveOccurred())
+		for pad, instrument := range program.Program.Instruments.Instrument {
+			Expect(program.Program.PadNoteMap.PadNote[pad].Number).To(Equal(fmt.Sprint(pad + 1)))
+			if pad < 12 {
+				Expect(program.Program.PadNoteMap.PadNote[pad].Note).To(Equal(60 + pad))
+				Expect(instrument.Layers.Layer).To(HaveLen(1), "pad %d", pad+1)
+			} else {
+				Expect(instrument.Layers.Layer).To(BeEmpty(), "pad %d", pad+1)
+			}
+		}
+	})
+})
@@ -0,0 +1,123 @@
+package convert
+
+import (
+	"math"
+
+	"k8s.io/klog"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+// ============================================================================
+// LFO
+// ============================================================================
+
+// Assumed range of the free running MPC LFO rate, approached by an
+// exponential function over [0,1] as the envelope times are. Akai documents
+// no Hz for the rate, and the programs in pkg/xpm/testdata only show its
+// default of 0.5, so the range is an approximation: 20 Hz is about the
+// fastest EXS rate, ApproxLfoRateHz(127), and 0.05 Hz a 20 second cycle.
+const (
+	approxMinLfoRateHz = 0.05
+	approxMaxLfoRateHz = 20.0
+)
+
+// exsLfoTypes maps the EXS LFO waveforms to the MPC ones. The MPC has no
+// positive only square and no smoothed random, they get the square and the
+// sample and hold.
+var exsLfoTypes = map[int16]string{
+	exs.LfoTriangle:     xpm.LfoTriangle,
+	exs.LfoSawDown:      xpm.LfoSawDown,
+	exs.LfoSawUp:        xpm.LfoSawUp,
+	exs.LfoSquare:       xpm.LfoSquare,
+	exs.LfoSquareUp:     xpm.LfoSquare,
+	exs.LfoRandom:       xpm.LfoRandom,
+	exs.LfoSmoothRandom: xpm.LfoRandom,
+}
+
+// lfo holds the settings of the MPC LFO.
+type lfo struct {
+	shape  string  // LFO Type
+	rate   float64 // Hz, 0 for the slowest rate
+	sync   int     // LFO Sync, 0 runs free at rate
+	delay  float64 // seconds the LFO waits after the note starts
+	fadeIn float64 // seconds the depth takes to rise after the delay
+	attack float64 // seconds the rate takes to rise after the delay
+}
+
+// convertLFO returns the settings of the EXS LFO source, and warns about what
+// the MPC LFO cannot play. source is ModSourceOff if no LFO is routed, LFO 1
+// is converted then without warnings. params may be nil.
+func convertLFO(name string, params *exs.Params, source exs.ModSource) lfo {
+	l := lfo{shape: xpm.LfoTriangle}
+	if params == nil {
+		return l
+	}
+	warn := source != exs.ModSourceOff
+	if !warn {
+		source = exs.ModSourceLFO1
+	}
+
+	rate, waveform, hz := params.Lfo1Rate, params.Lfo1Waveform, params.Lfo1RateHz()
+	switch source {
+	case exs.ModSourceLFO2:
+		rate, waveform, hz = params.Lfo2Rate, params.Lfo2Waveform, params.Lfo2RateHz()
+	case exs.ModSourceLFO3:
+		rate, waveform, hz = params.Lfo3Rate, exs.LfoTriangle, params.Lfo3RateHz()
+	}
+
+	if shape, ok := exsLfoTypes[waveform]; ok {
+		l.shape = shape
+	} else if warn {
+		klog.Warningf("%s: unknown waveform %d of %s, using a triangle", name, waveform, source)
+	}
+	if exs.LfoSynced(rate) {
+		// The note values of the synced EXS rates are not known
+		l.sync = lfoSync(1)
+		if warn {
+			klog.Warningf("%s: %s is synced to an unknown note value, the MPC LFO syncs to a quarter note", name, source)
+		}
+	} else {
+		l.rate = hz
+		if hz == 0 && warn {
+			klog.Warningf("%s: %s is stopped, the MPC LFO runs at its slowest rate", name, source)
+		}
+	}
+
+	// The EXS LFOs start with the note at their full rate, so delay and
+	// attack stay 0. LFO 1 fades in after a positive delay and out after a
+	// negative one: the EXS24 ramps the depth from the note start, which is
+	// the MPC fade in.
+	if source == exs.ModSourceLFO1 {
+		switch fade := params.Lfo1DecayDelaySeconds(); {
+		case fade > 0:
+			l.fadeIn = fade
+		case fade < 0 && warn:
+			klog.Warningf("%s: LFO 1 fades out over %.3fs, the MPC LFO only fades in", name, -fade)
+		}
+	}
+	return l
+}
+
+// lfoSync returns the LFO Sync value whose note value is closest to beats
+// quarter notes.
+func lfoSync(beats float64) int {
+	best := 0
+	for i, b := range xpm.LfoSyncBeats {
+		if best == 0 || math.Abs(math.Log(b/beats)) < math.Abs(math.Log(xpm.LfoSyncBeats[best-1]/beats)) {
+			best = i + 1
+		}
+	}
+	return best
+}
+
+// apply sets the LFO of instrument. The amounts are set by the modulation.
+func (l lfo) apply(instrument *xpm.Instrument) {
+	instrument.LFO.Type = l.shape
+	instrument.LFO.Rate = xpm.Normalized(normalizeLogarithmicEnvTimeValue(l.rate, approxMinLfoRateHz, approxMaxLfoRateHz))
+	instrument.LFO.Sync = l.sync
+	instrument.LFO.Delay = formatEnvTime(l.delay)
+	instrument.LFO.FadeIn = formatEnvTime(l.fadeIn)
+	instrument.LFO.Attack = formatEnvTime(l.attack)
+}
@@ -0,0 +1,202 @@
+package convert
+
+import (
+	"math"
+
+	"k8s.io/klog"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+// ============================================================================
+// Modulation
+// ============================================================================
+
+// modulation holds what the routings of an EXS modulation matrix set on the
+// MPC instruments and program. Amounts add up when several routings reach
+// the same target. The MPC has no negative amounts except for the pitch
+// envelope.
+type modulation struct {
+	lfoPitch, lfoCutoff, lfoVolume, lfoPan float64
+	wheelToLfo                             float64 // share of the LFO depth the mod wheel controls, 0 leaves the program default
+
+	velocityToFilter, velocityToPitch, velocityToPan float64
+	velocityToStart                                  float64
+	velocityToFilterAttack, velocityToVolumeAttack   float64
+	velocityToVolume                                 float64 // 0 leaves the template sensitivity
+	afterTouchToFilter                               float64
+	filterKeytrack                                   float64
+	filterEnvAmount                                  float64 // 0 leaves the template amount
+	pitchEnvAmount                                   float64 // [-1,1]
+
+	lfo exs.ModSource // EXS LFO the MPC LFO plays, ModSourceOff if none is routed
+}
+
+// convertModulation returns the modulation of the routings in params, and
+// warns about each routing the MPC cannot play. params may be nil.
+func convertModulation(name string, params *exs.Params) modulation {
+	m := modulation{lfo: exs.ModSourceOff}
+	if params == nil {
+		return m
+	}
+	m.filterKeytrack = params.FilterViaKeyNormalized()
+
+	lfos := make(map[exs.ModSource]bool)
+	for _, r := range params.ModRoutings() {
+		// The mod wheel scaled by an LFO is the LFO scaled by the mod wheel
+		if r.Source == exs.ModSourceModWheel && isLFO(r.Via) {
+			r.Source, r.Via = r.Via, r.Source
+			r.Invert, r.InvertVia = r.InvertVia, r.Invert
+		}
+		if reason := m.add(r); reason != "" {
+			klog.Warningf("%s: modulation %s is lost: %s", name, r, reason)
+		} else if isLFO(r.Source) {
+			lfos[r.Source] = true
+			if m.lfo == exs.ModSourceOff {
+				m.lfo = r.Source
+			}
+		}
+	}
+	if len(lfos) > 1 {
+		klog.Warningf("%s: the MPC has one LFO, the routings of %d EXS LFOs share it at the rate of %s", name, len(lfos), m.lfo)
+	}
+	return m
+}
+
+// add adds the routing r, or returns why the MPC cannot play it.
+func (m *modulation) add(r exs.ModRouting) string {
+	if isLFO(r.Source) {
+		return m.addLFO(r)
+	}
+	if r.Source == exs.ModSourceModWheel && !r.HasVia() {
+		switch r.Destination {
+		case exs.ModDestLFO1Amount, exs.ModDestLFO2Amount, exs.ModDestLFO3Amount:
+			m.wheelToLfo = math.Max(m.wheelToLfo, math.Abs(r.Amount))
+			return ""
+		}
+	}
+	if r.HasVia() {
+		return "the MPC scales only LFOs by the mod wheel"
+	}
+
+	amount := r.Amount
+	if r.Invert {
+		amount = -amount
+	}
+	if r.Source == exs.ModSourceEnv1 && r.Destination == exs.ModDestPitch {
+		m.pitchEnvAmount += amount
+		return ""
+	}
+	target := m.target(r.Source, r.Destination)
+	if target == nil {
+		return "the MPC has no such modulation"
+	}
+	if amount < 0 {
+		return "the MPC has no negative amount"
+	}
+	*target += amount
+	return ""
+}
+
+// addLFO adds the routing r of an LFO. A mod wheel via sets the share of
+// the depth the wheel controls.
+func (m *modulation) addLFO(r exs.ModRouting) string {
+	var target *float64
+	switch r.Destination {
+	case exs.ModDestPitch:
+		target = &m.lfoPitch
+	case exs.ModDestFilterCutoff:
+		target = &m.lfoCutoff
+	case exs.ModDestVolume, exs.ModDestRelativeVolume:
+		target = &m.lfoVolume
+	case exs.ModDestPan:
+		target = &m.lfoPan
+	default:
+		return "the MPC LFO does not reach " + r.Destination.String()
+	}
+	if r.HasVia() && r.Via != exs.ModSourceModWheel {
+		return "the MPC scales LFOs only by the mod wheel"
+	}
+
+	// The sign of an LFO amount only shifts its phase
+	low, high := math.Abs(r.Amount), math.Abs(r.AmountVia)
+	depth := math.Max(low, high)
+	*target += depth
+	if r.HasVia() && high > low {
+		m.wheelToLfo = math.Max(m.wheelToLfo, (high-low)/depth)
+	}
+	return ""
+}
+
+// target returns the amount the source modulating dest sets, nil if the
+// MPC has none.
+func (m *modulation) target(source exs.ModSource, dest exs.ModDestination) *float64 {
+	switch source {
+	case exs.ModSourceVelocity:
+		switch dest {
+		case exs.ModDestFilterCutoff:
+			return &m.velocityToFilter
+		case exs.ModDestPitch:
+			return &m.velocityToPitch
+		case exs.ModDestPan:
+			return &m.velocityToPan
+		case exs.ModDestSampleStart:
+			return &m.velocityToStart
+		case exs.ModDestEnv1Attack:
+			return &m.velocityToFilterAttack
+		case exs.ModDestEnv2Attack:
+			return &m.velocityToVolumeAttack
+		case exs.ModDestVolume, exs.ModDestRelativeVolume:
+			return &m.velocityToVolume
+		}
+	case exs.ModSourceAftertouch, exs.ModSourcePolyAftertouch:
+		if dest == exs.ModDestFilterCutoff {
+			return &m.afterTouchToFilter
+		}
+	case exs.ModSourceKey:
+		if dest == exs.ModDestFilterCutoff {
+			return &m.filterKeytrack
+		}
+	case exs.ModSourceEnv1:
+		if dest == exs.ModDestFilterCutoff {
+			return &m.filterEnvAmount
+		}
+	}
+	return nil
+}
+
+// apply sets the modulation of instrument.
+func (m *modulation) apply(instrument *xpm.Instrument) {
+	instrument.LfoPitch = xpm.Normalized(m.lfoPitch)
+	instrument.LfoCutoff = xpm.Normalized(m.lfoCutoff)
+	instrument.LfoVolume = xpm.Normalized(m.lfoVolume)
+	instrument.LfoPan = xpm.Normalized(m.lfoPan)
+	instrument.VelocityToFilter = xpm.Normalized(m.velocityToFilter)
+	instrument.VelocityToPitch = xpm.Normalized(m.velocityToPitch)
+	instrument.VelocityToPan = xpm.Normalized(m.velocityToPan)
+	instrument.VelocityToStart = xpm.Normalized(m.velocityToStart)
+	instrument.VelocityToFilterAttack = xpm.Normalized(m.velocityToFilterAttack)
+	instrument.VelocityToVolumeAttack = xpm.Normalized(m.velocityToVolumeAttack)
+	instrument.AfterTouchToFilter = xpm.Normalized(m.afterTouchToFilter)
+	instrument.FilterKeytrack = xpm.Normalized(m.filterKeytrack)
+	instrument.PitchEnvAmount = xpm.Normalized(0.5 + m.pitchEnvAmount/2)
+	if m.velocityToVolume > 0 {
+		instrument.VelocitySensitivity = xpm.Normalized(m.velocityToVolume)
+	}
+	if m.filterEnvAmount > 0 {
+		instrument.FilterEnvAmt = xpm.Normalized(m.filterEnvAmount)
+	}
+}
+
+// applyProgram sets the program wide modulation of program.
+func (m *modulation) applyProgram(program *xpm.Program) {
+	if m.wheelToLfo > 0 {
+		program.KeygroupWheelToLfo = xpm.Normalized(m.wheelToLfo)
+	}
+}
+
+// isLFO reports whether s is one of the EXS LFOs.
+func isLFO(s exs.ModSource) bool {
+	return s == exs.ModSourceLFO1 || s == exs.ModSourceLFO2 || s == exs.ModSourceLFO3
+}
@@ -3,7 +3,7 @@ package convert
 // TODO: Implement missing features from ConvertWithMoss for full XPM compatibility:
 // 1. ✅ Envelope Curves: Add attack/decay/release curves for amplitude and filter envelopes - COMPLETED
 // 2. ✅ LFO Settings: Add per-instrument LFO with pitch/cutoff/volume/pan modulation - COMPLETED
-// 3. Advanced Filter Features: Add filter envelope curves and more filter types - PARTIALLY COMPLETED (curves added, types need refinement)
+// 3. ✅ Advanced Filter Features: Add filter envelope curves and more filter types - COMPLETED
 // 4. ✅ Pitch Envelope: Implement full pitch envelope support - COMPLETED
 // 5. Zone Play Modes: Add different trigger modes (one-shot, note-off, etc.) - PARTIALLY COMPLETED (TriggerMode field added)
 // 6. ✅ XML Tag Constants: Create comprehensive constants file like MPCKeygroupTag.java - COMPLETED
@@ -16,6 +16,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"k8s.io/klog"
@@ -35,10 +36,13 @@ type XPM struct {
 	OutputPath          string
 	LayersPerInstrument int
 	SkipErrors          bool
-	ProgramType         string            // "Keygroup" or "Drum" - empty for auto-detect
-	AutoDetectDrums     bool              // If true, auto-detect drum programs
-	SamplesSearchPath   string            // Path to search for samples (defaults to SearchPath)
-	sampleIndex         map[string]string // Cache: filename -> full path
+	ProgramType         string         // "Keygroup" or "Drum" - empty for auto-detect
+	AutoDetectDrums     bool           // If true, auto-detect drum programs
+	SamplesSearchPath   string         // Path to search for samples (defaults to SearchPath)
+	PathRules           []exs.PathRule // Rewrite sample directories stored in EXS files
+	Target              *xpm.Profile   // MPC the programs are written for
+	Layout              *DrumLayout    // pads the notes of drum kits go to
+	samples             *exs.SampleResolver
 }
 
 func NewXPM(searchPath, outputPath string, layersPerInstrument int, skipErrors bool, programType string) *XPM {
@@ -54,6 +58,8 @@ func NewXPM(searchPath, outputPath string, layersPerInstrument int, skipErrors b
 		ProgramType:         programType,
 		AutoDetectDrums:     false, // Default to manual
 		SamplesSearchPath:   searchPath,
+		Target:              xpm.DefaultProfile(),
+		Layout:              defaultDrumLayout(),
 	}
 }
 
@@ -196,44 +202,21 @@ func (x *XPM) findEXSFiles() ([]string, error) {
 // buildSampleIndex walks the samples directory once and builds an index of all sample files.
 // This dramatically improves performance when processing instruments with many samples.
 func (x *XPM) buildSampleIndex() error {
-	x.sampleIndex = make(map[string]string)
-
 	searchPath := x.SamplesSearchPath
 	if searchPath == "" {
 		searchPath = x.SearchPath
 	}
 
 	klog.V(3).Infof("Building sample index from %s", searchPath)
-	count := 0
-
-	err := filepath.WalkDir(searchPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-
-		filename := d.Name()
-		if existingPath, exists := x.sampleIndex[filename]; exists {
-			klog.V(3).Infof("Warning: duplicate sample file %s (keeping %s, ignoring %s)", filename, existingPath, path)
-			return nil
-		}
-
-		x.sampleIndex[filename] = path
-		count++
-		return nil
-	})
-
+	samples, err := exs.NewSampleResolver(x.PathRules, searchPath)
 	if err != nil {
 		return err
 	}
-
-	klog.V(2).Infof("Indexed %d sample files", count)
+	x.samples = samples
 	return nil
 }
 
-// copySample searches for a sample file in the SamplesSearchPath directory tree,
+// copySample searches for a sample file by name in the SamplesSearchPath directory tree,
 // copies it to the destination directory, and converts the extension to uppercase (.WAV).
 // This ensures MPC compatibility: sample files must be in the same directory as the XPM file.
 //
@@ -242,12 +225,26 @@ func (x *XPM) buildSampleIndex() error {
 //   - destPath: The destination directory (same as the XPM file location)
 //
 // Returns the sample name without extension and the sample filename with uppercase extension,
-// or an error if the sample is not found or if multiple samples with the same name exist.
+// or an error if the sample is not found.
 func (x *XPM) copySample(name, destPath string) (string, string, error) {
-	// Use pre-built sample index for fast lookup
-	src, found := x.sampleIndex[name]
-	if !found {
-		return "", "", fmt.Errorf("no sample found for %s", name)
+	return x.copyExsSample(&exs.Sample{FileName: name}, "", destPath)
+}
+
+// copyExsSample resolves an EXS sample using its stored path, the path of the .exs file
+// and the sample index, then copies it like copySample.
+func (x *XPM) copyExsSample(sample *exs.Sample, exsPath, destPath string) (string, string, error) {
+	if x.samples == nil {
+		if err := x.buildSampleIndex(); err != nil {
+			return "", "", err
+		}
+	}
+	name := strings.TrimSpace(sample.FileName)
+	src, err := x.samples.Resolve(sample, exsPath)
+	if err != nil {
+		return "", "", fmt.Errorf("no sample found for %s: %w", name, err)
+	}
+	if name == "" {
+		name = filepath.Base(src)
 	}
 
 	klog.V(2).Infof("found %s", src)
@@ -301,9 +298,17 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 	} else {
 		keyGroup = xpm.NewXPMKeygroup()
 	}
+	if err := keyGroup.SetProfile(x.Target); err != nil {
+		return err
+	}
 
-	klog.V(2).Infof("Calling GetZonesByKeyRanges with %d layers", x.LayersPerInstrument)
-	z := exsFile.GetZonesByKeyRanges(x.LayersPerInstrument)
+	layers := x.LayersPerInstrument
+	if layers > x.Target.MaxLayers {
+		klog.Warningf("%s: %s supports %d layers per keygroup, not %d", exsFile.Name, x.Target.Description, x.Target.MaxLayers, layers)
+		layers = x.Target.MaxLayers
+	}
+	klog.V(2).Infof("Calling GetZonesByKeyRanges with %d layers", layers)
+	z := exsFile.GetZonesByKeyRanges(layers)
 	klog.V(2).Infof("GetZonesByKeyRanges returned %d instruments", len(z))
 	for s, zoneMap := range z {
 		klog.V(5).Infof("zoneMap: %d", s)
@@ -319,11 +324,11 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 		klog.V(2).Infof("Number of instruments: %d", len(z))
 	}
 
-	if len(z) >= 128 {
+	if len(z) > x.Target.MaxKeygroups {
 		if !x.SkipErrors {
-			return fmt.Errorf("%s too many instruments", exsFile.Name)
+			return fmt.Errorf("%s too many instruments for %s", exsFile.Name, x.Target.Description)
 		} else {
-			klog.Warningf("Skipping %s due to too many instruments (%d)", exsFile.Name, len(z))
+			klog.Warningf("Skipping %s due to too many instruments (%d, %s supports %d)", exsFile.Name, len(z), x.Target.Description, x.Target.MaxKeygroups)
 			return nil
 		}
 	}
@@ -337,26 +342,53 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 	groupMap := make(map[uint32]*exs.Group)
 	for i := range groups {
 		groupMap[groups[i].ID] = groups[i]
-		klog.V(2).Infof("group: %s, id: %d, selectgroup: %d, sequences: %+v, selectType: %d, selectNumber: %d", groups[i].Name, groups[i].ID, groups[i].SelectGroup, exsFile.Sequences, groups[i].SelectType, groups[i].SelectNumber)
+		klog.V(2).Infof("group: %s, id: %d, selectgroup: %d, selectType: %d, selectNumber: %d", groups[i].Name, groups[i].ID, groups[i].SelectGroup, groups[i].SelectType, groups[i].SelectNumber)
 	}
 
 	// Check for Round Robin support across groups
-	// Round Robin is enabled when SelectGroup >= 0 in any group
-	hasRoundRobin := false
-	for _, g := range groups {
-		if g.SelectGroup >= 0 {
-			hasRoundRobin = true
-			klog.V(2).Infof("Round Robin detected: group %d has SelectGroup=%d", g.ID, g.SelectGroup)
-			break
-		}
+	// A group plays round robin when its set has at least two groups with zones
+	roundRobin := roundRobinGroups(exsFile, groupMap)
+
+	// Groups whose voices cut each other share a mute group
+	mute := muteGroups(exsFile, groupMap)
+
+	// Routings of the modulation matrix the MPC can play
+	mod := convertModulation(exsFile.Name, exsFile.Params)
+	mod.applyProgram(&keyGroup.Program)
+	wave := convertLFO(exsFile.Name, exsFile.Params, mod.lfo)
+
+	// Voice mode, polyphony and glide of the instrument
+	if exsFile.Params != nil {
+		convertVoiceMode(exsFile.Name, exsFile.Params, &keyGroup.Program)
 	}
 
 	// Use the EXS instrument name as the program name
 	keyGroup.Program.ProgramName = exsFile.Name
 
-	j := 0
+	// Lay the kit out on the pads: each pad sends the note of the sounds
+	// placed on it, so the zones below find their pad through PadNoteMap
+	if x.ProgramType == ProgramTypeDrum {
+		padNotes := make(map[int]int)
+		lowest := padCount
+		for note, pad := range x.Layout.Place(drumSounds(exsFile, groupMap)) {
+			padNotes[pad] = note
+			lowest = min(lowest, pad)
+		}
+		if len(padNotes) > 0 && lowest >= bankSize {
+			klog.Warningf("%s: layout %s leaves pad bank A empty", exsFile.Name, x.Layout.Name)
+		}
+		keyGroup.Program.PadNoteMap.SetNotes(padNotes)
+		// A pad keeps the settings of the first group placed on it
+		sortZoneSets(exsFile, z)
+	}
+
+	padGroups := make(map[int]*exs.Group) // group whose settings each drum pad plays
+	filled := 0                           // keygroups filled so far
 	for _, zoneMap := range z {
 		for _, zones := range zoneMap {
+			j := filled
+			var kept *xpm.Instrument // settings of a pad another group plays from
+			var keptGroup *exs.Group
 			// Look up the group for this zone
 			g, ok := groupMap[uint32(zones[0].GroupIndex)]
 			if !ok {
@@ -385,13 +417,21 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				continue
 			}
 
-			// For Drum programs: each zone maps to a single pad/note
+			// For Drum programs: instrument N plays from pad N, which sends the
+			// note PadNoteMap gives it, so the zones go to the pad of their key
 			// For Keygroup programs: zones can span multiple notes
 			if x.ProgramType == ProgramTypeDrum {
-				// In drum mode, use the zone's key low as the pad note
-				// Each zone gets mapped to its specific MIDI note
-				keyGroup.Program.Instruments.Instrument[j].LowNote = zoneKeyLow
-				keyGroup.Program.Instruments.Instrument[j].HighNote = zoneKeyLow // Same as LowNote for drums
+				pad, ok := keyGroup.Program.PadNoteMap.Pad(zoneKeyLow)
+				if !ok {
+					klog.Warningf("%s: no pad plays note %d, skipping %q", exsFile.Name, zoneKeyLow, zones[0].Name)
+					continue
+				}
+				j = pad
+				if first, ok := padGroups[j]; ok {
+					kept = &xpm.Instrument{}
+					*kept = keyGroup.Program.Instruments.Instrument[j]
+					keptGroup = first
+				}
 			} else {
 				// Keygroup mode: use full key range (with group limits applied)
 				keyGroup.Program.Instruments.Instrument[j].LowNote = zoneKeyLow
@@ -401,38 +441,47 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 			// Filter parameters - convert from EXS int8 range to XPM normalized values
 			keyGroup.Program.Instruments.Instrument[j].Cutoff = formatFilterCutoff(float64(g.Cutoff))
 			keyGroup.Program.Instruments.Instrument[j].Resonance = formatFilterResonance(float64(g.Resonance))
+			// Filter type from the instrument filter
+			if exsFile.Params != nil {
+				if filterType, ok := convertFilterType(exsFile.Params); ok {
+					keyGroup.Program.Instruments.Instrument[j].FilterType = filterType
+				}
+			}
+			// Modulation matrix, with the filter key tracking and envelope amounts
+			mod.apply(&keyGroup.Program.Instruments.Instrument[j])
 			// Volume envelope - convert from EXS time units to XPM normalized values
 			// EXS envelope times appear to be in some normalized unit, convert to 0-1 range for XPM
 
 			// Use envelope parameters from EXS Params (global instrument settings) instead of Group settings
+			// Times are in seconds, sustain levels in 0-1
 			var volAttack, volDecay, volSustain, volRelease, volHold float64
 			var filtAttack, filtDecay, filtSustain, filtRelease, filtHold float64
 
 			if exsFile.Params != nil {
-				volAttack = float64(exsFile.Params.Env2Attack)
-				volDecay = float64(exsFile.Params.Env2Decay)
-				volSustain = float64(exsFile.Params.Env2Sustain)
-				volRelease = float64(exsFile.Params.Env2Release)
+				volAttack = exsFile.Params.Env2AttackSeconds()
+				volDecay = exsFile.Params.Env2DecaySeconds()
+				volSustain = exsFile.Params.Env2SustainLevel()
+				volRelease = exsFile.Params.Env2ReleaseSeconds()
 				// Note: Hold2 from group might be used, but for now use 0
 				volHold = 0
 
-				filtAttack = float64(exsFile.Params.Env1Attack)
-				filtDecay = float64(exsFile.Params.Env1Decay)
-				filtSustain = float64(exsFile.Params.Env1Sustain)
-				filtRelease = float64(exsFile.Params.Env1Release)
+				filtAttack = exsFile.Params.Env1AttackSeconds()
+				filtDecay = exsFile.Params.Env1DecaySeconds()
+				filtSustain = exsFile.Params.Env1SustainLevel()
+				filtRelease = exsFile.Params.Env1ReleaseSeconds()
 				filtHold = 0 // EXS doesn't have separate hold for filter envelope
 			} else {
 				// Fallback to group values (though they appear to be 0)
-				volAttack = float64(g.Attack2)
-				volDecay = float64(g.Decay2)
-				volSustain = float64(g.Sustain2)
-				volRelease = float64(g.Release2)
-				volHold = float64(g.Hold2)
-
-				filtAttack = float64(g.Attack1)
-				filtDecay = float64(g.Decay1)
-				filtSustain = float64(g.Sustain1)
-				filtRelease = float64(g.Release1)
+				volAttack = groupEnvTime(g.Attack2)
+				volDecay = groupEnvTime(g.Decay2)
+				volSustain = groupEnvLevel(g.Sustain2)
+				volRelease = groupEnvTime(g.Release2)
+				volHold = groupEnvTime(g.Hold2)
+
+				filtAttack = groupEnvTime(g.Attack1)
+				filtDecay = groupEnvTime(g.Decay1)
+				filtSustain = groupEnvLevel(g.Sustain1)
+				filtRelease = groupEnvTime(g.Release1)
 			}
 
 			keyGroup.Program.Instruments.Instrument[j].VolumeAttack = formatEnvTime(volAttack)
@@ -452,7 +501,7 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 			// Filter envelope - ENV2 in EXS is the filter envelope
 			// Only apply filter envelope if FilterEnvAmt > 0 (ConvertWithMoss logic)
 			// MPC does not support negative filter modulation
-			if keyGroup.Program.Instruments.Instrument[j].FilterEnvAmt != "" && keyGroup.Program.Instruments.Instrument[j].FilterEnvAmt != "0.000000" {
+			if keyGroup.Program.Instruments.Instrument[j].FilterEnvAmt > 0 {
 				keyGroup.Program.Instruments.Instrument[j].FilterAttack = formatEnvTime(filtAttack)
 				keyGroup.Program.Instruments.Instrument[j].FilterDecay = formatEnvTime(filtDecay)
 				keyGroup.Program.Instruments.Instrument[j].FilterSustain = formatEnvLevel(filtSustain)
@@ -482,20 +531,23 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 			}
 			keyGroup.Program.Instruments.Instrument[j].PitchDecayCurve = getDefaultEnvelopeCurve()
 			keyGroup.Program.Instruments.Instrument[j].PitchReleaseCurve = getDefaultEnvelopeCurve()
-			keyGroup.Program.Instruments.Instrument[j].PitchEnvAmount = "0"
 			// Trigger mode - set based on group's Trigger field
 			// Trigger == 1 means release-triggered samples (like piano sympathetic resonance)
-			// TriggerMode: 0=one-shot, 1=release, 2=normal attack
-			if g.Trigger == 1 {
+			// TriggerMode: 0=one-shot, 1=release, 2=normal attack. From MPC
+			// OS 2.10 on it replaces OneShot, so one-shot zones set it too
+			switch {
+			case g.Trigger == 1:
 				keyGroup.Program.Instruments.Instrument[j].TriggerMode = 1 // Release trigger
 				klog.V(2).Infof("Setting release trigger for instrument %d (group %d)", j, zones[0].GroupIndex)
-			} else {
+			case len(zones) > 0 && zones[0].OneShot:
+				keyGroup.Program.Instruments.Instrument[j].TriggerMode = 0 // One-shot
+			default:
 				keyGroup.Program.Instruments.Instrument[j].TriggerMode = 2 // Normal attack trigger
 			}
 
 			// Set ZonePlay for Round Robin if enabled
 			// ZonePlay values: 0=CYCLE (round robin), 1=VELOCITY, 2=RANDOM
-			if hasRoundRobin && g.SelectGroup >= 0 {
+			if roundRobin[g.ID] {
 				keyGroup.Program.Instruments.Instrument[j].ZonePlay = 0 // CYCLE = Round Robin
 				klog.V(2).Infof("Setting Round Robin (ZonePlay=0) for instrument %d (group %d, SelectGroup=%d)",
 					j, zones[0].GroupIndex, g.SelectGroup)
@@ -503,30 +555,47 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				keyGroup.Program.Instruments.Instrument[j].ZonePlay = 1 // VELOCITY (default)
 			}
 
-			// Phase 2: One-shot mode - map from first zone in group
-			// OneShot: "True" = sample plays once without looping (ignores note-off)
-			if len(zones) > 0 && zones[0].OneShot {
-				keyGroup.Program.Instruments.Instrument[j].OneShot = "True"
-			} else {
-				keyGroup.Program.Instruments.Instrument[j].OneShot = "False"
+			// Voices - a group of one voice plays mono, more limit the
+			// polyphony, 0 leaves the program default. Zones outside any
+			// group keep the defaults rather than those of the fallback group
+			if group, ok := groupMap[uint32(zones[0].GroupIndex)]; ok {
+				switch {
+				case group.Polyphony == 1:
+					keyGroup.Program.Instruments.Instrument[j].Mono = true
+					keyGroup.Program.Instruments.Instrument[j].Polyphony = 1
+				case group.Polyphony > 1:
+					keyGroup.Program.Instruments.Instrument[j].Polyphony = int(group.Polyphony)
+				}
+				if number := mute[group.ID]; number != 0 {
+					keyGroup.Program.Instruments.Instrument[j].MuteGroup = number
+				}
 			}
 
+			// Phase 2: One-shot mode - map from first zone in group
+			// OneShot: true = sample plays once without looping (ignores note-off)
+			keyGroup.Program.Instruments.Instrument[j].OneShot = xpm.Bool(len(zones) > 0 && zones[0].OneShot)
+
 			// Phase 2: Output routing - map from zone output to AudioRoute
 			// Output: 0-15 in EXS maps to different output channels/busses
 			if len(zones) > 0 && zones[0].HasOutput {
 				keyGroup.Program.Instruments.Instrument[j].AudioRoute.AudioRoute = convertOutputToAudioRouteInt(int(zones[0].ExsZone.Output))
 			}
 
-			// LFO - initialize with default values
-			keyGroup.Program.Instruments.Instrument[j].LFO.Type = "Triangle"
-			keyGroup.Program.Instruments.Instrument[j].LFO.Rate = "0"
-			keyGroup.Program.Instruments.Instrument[j].LFO.Sync = 0
-			keyGroup.Program.Instruments.Instrument[j].LFO.Reset = "False"
-			keyGroup.Program.Instruments.Instrument[j].LFO.PitchAmount = "0"
-			keyGroup.Program.Instruments.Instrument[j].LFO.CutoffAmount = "0"
-			keyGroup.Program.Instruments.Instrument[j].LFO.VolumeAmount = "0"
-			keyGroup.Program.Instruments.Instrument[j].LFO.PanAmount = "0"
+			// LFO - the waveform, rate and fade in of the routed EXS LFO
+			wave.apply(&keyGroup.Program.Instruments.Instrument[j])
+			keyGroup.Program.Instruments.Instrument[j].LFO.Reset = false
+			keyGroup.Program.Instruments.Instrument[j].LFO.PitchAmount = 0
+			keyGroup.Program.Instruments.Instrument[j].LFO.CutoffAmount = 0
+			keyGroup.Program.Instruments.Instrument[j].LFO.VolumeAmount = 0
+			keyGroup.Program.Instruments.Instrument[j].LFO.PanAmount = 0
 			keyGroup.Program.Instruments.Instrument[j].Volume = convertGain(float64(g.Volume))
+			if kept != nil {
+				if differ := instrumentConflicts(kept, &keyGroup.Program.Instruments.Instrument[j]); len(differ) > 0 && keptGroup != g {
+					klog.Warningf("%s: groups %q and %q play from pad %d with a different %s, keeping those of %q",
+						exsFile.Name, keptGroup.Name, g.Name, j+1, strings.Join(differ, ", "), keptGroup.Name)
+				}
+				keyGroup.Program.Instruments.Instrument[j] = *kept
+			}
 			klog.V(2).Infof("Instrument: %s, LowNote: %d, HighNote: %d\n", keyGroup.Program.Instruments.Instrument[j].Number, keyGroup.Program.Instruments.Instrument[j].LowNote, keyGroup.Program.Instruments.Instrument[j].HighNote)
 
 			// First pass: count valid layers (zones with successfully copied samples)
@@ -558,12 +627,29 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				continue
 			}
 
-			// Allocate layer array
-			keyGroup.Program.Instruments.Instrument[j].Layers.Layer = make([]xpm.Layer, validLayerCount)
+			// Allocate layer array, after the layers of other groups on the same pad
+			firstLayer := 0
+			if x.ProgramType == ProgramTypeDrum {
+				firstLayer = len(keyGroup.Program.Instruments.Instrument[j].Layers.Layer)
+			}
+			if firstLayer+validLayerCount > x.Target.MaxLayers {
+				klog.Warningf("%s: pad %d has %d layers, %s plays %d", exsFile.Name, j+1, firstLayer+validLayerCount, x.Target.Description, x.Target.MaxLayers)
+				validLayerCount = x.Target.MaxLayers - firstLayer
+				if validLayerCount <= 0 {
+					continue
+				}
+			}
+			keyGroup.Program.Instruments.Instrument[j].Layers.Layer = append(keyGroup.Program.Instruments.Instrument[j].Layers.Layer[:firstLayer], make([]xpm.Layer, validLayerCount)...)
+			if _, ok := padGroups[j]; !ok && x.ProgramType == ProgramTypeDrum {
+				padGroups[j] = g
+			}
 
 			// Second pass: populate layers
-			layerIdx := 0
+			layerIdx := firstLayer
 			for _, zone := range zones {
+				if layerIdx == len(keyGroup.Program.Instruments.Instrument[j].Layers.Layer) {
+					break
+				}
 				// Apply group velocity range limits to layers
 				layerVelLow := int(zone.VelLow)
 				layerVelHigh := int(zone.VelHigh)
@@ -584,7 +670,7 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				}
 
 				sampleName := strings.TrimSpace(exsFile.Samples[zone.SampleIndex].FileName)
-				xpmSampleName, xpmSampleFile, err := x.copySample(sampleName, destPath)
+				xpmSampleName, xpmSampleFile, err := x.copyExsSample(exsFile.Samples[zone.SampleIndex], exsFile.FilePath, destPath)
 				if err != nil {
 					klog.Warningf("Failed to copy sample '%s': %v", sampleName, err)
 					// This shouldn't happen since we already counted valid layers,
@@ -594,9 +680,9 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				klog.V(2).Infof("Successfully copied sample: %s", sampleName)
 				// layers - use group-limited velocity ranges
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Number = fmt.Sprintf("%d", layerIdx+1)
-				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Active = "True"
-				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Pitch = "0.000000"
-				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Mute = "False"
+				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Active = true
+				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Pitch = 0
+				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Mute = false
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].VelStart = layerVelLow
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].VelEnd = layerVelHigh
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].SampleName = xpmSampleName
@@ -612,15 +698,11 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Offset = int(zone.Offset)
 
 				// Phase 1: Loop parameters - map EXS zone loop settings to XPM layer
-				// Loop: "True" or "False" string to enable/disable looping
+				// Loop: enables or disables looping
 				// LoopStart/LoopEnd: loop points in samples
 				// LoopCrossfadeLength: crossfade length for smooth loops
 				// LoopTune: fine-tune adjustment for loop region
-				if zone.LoopOn {
-					keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Loop = "True"
-				} else {
-					keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Loop = "False"
-				}
+				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].Loop = xpm.Bool(zone.LoopOn)
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].LoopStart = int(zone.LoopStart)
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].LoopEnd = int(zone.LoopEnd)
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].LoopCrossfadeLength = int(zone.LoopCrossfade)
@@ -672,7 +754,7 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 				// - When writing XPM: add 1 to the MIDI note
 				// - When reading XPM: subtract 1 from the stored value
 				// See: https://github.com/git-moss/ConvertWithMoss/blob/main/src/main/java/de/mossgrabers/convertwithmoss/format/akai/MPCKeygroupCreator.java#L224
-				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].RootNote = rootNote + 1
+				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].SetMIDIRootNote(rootNote)
 
 				// Phase 1: Zone volume and pan - map EXS zone mixing to XPM layer
 				// Volume: convert from dB (-60 to +12) to linear (0.0 to ~2.0)
@@ -682,43 +764,35 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 
 				// Phase 2: Zone scale (key tracking) - map EXS zone scale to XPM layer KeyTrack
 				// Scale: -100 to +100 in EXS, controls how much pitch changes with key
-				// KeyTrack: "1.000000" = full tracking, "0.000000" = no tracking (fixed pitch)
+				// KeyTrack: true = pitch follows the key, false = fixed pitch
 				keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].KeyTrack = convertScaleToKeyTrack(int(zone.Scale))
 				klog.V(2).Infof("  Layer: %d, VelStart: %d, VelEnd: %d, SampleFile: %s\n", layerIdx, keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].VelStart, keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].VelEnd, keyGroup.Program.Instruments.Instrument[j].Layers.Layer[layerIdx].SampleFile)
 				layerIdx++
 			}
-			j++
+			filled++
 		}
 	}
 
 	keyGroup.Program.KeygroupNumKeygroups = len(z)
 
+	// Pads of a mute group also name each other as mute targets
+	if x.ProgramType == ProgramTypeDrum {
+		setMuteTargets(&keyGroup.Program)
+	}
+
 	// Resize the Instrument array to only include the actual instruments created
 	// This prevents empty instruments with LowNote=0, HighNote=127 from being written to the XPM
-	if j < len(keyGroup.Program.Instruments.Instrument) {
-		keyGroup.Program.Instruments.Instrument = keyGroup.Program.Instruments.Instrument[:j]
-		klog.V(2).Infof("Resized instrument array from 128 to %d actual instruments", j)
-	}
-
-	// Global pitch bend range - use the first group's settings as global
-	// ConvertWithMoss applies this to all zones uniformly
-	if len(groups) > 0 {
-		// Calculate global pitch bend range
-		// EXS doesn't have a direct global pitch bend, but we can use group settings
-		// For now, use a default of 2 semitones (standard MIDI)
-		keyGroup.Program.KeygroupPitchBendRange = "0.166667" // 2 semitones / 12 = 0.166667
-		klog.V(2).Infof("Set global pitch bend range to 2 semitones")
-
-		// Global master transpose - sum of all group tuning offsets
-		// This is an approximation since EXS doesn't have explicit global transpose
-		globalTranspose := 0.0
-		for _, g := range groups {
-			// Groups might have pitch offsets that act as transpose
-			// For now, we'll leave this at 0 but log the capability
-			_ = g
-		}
-		keyGroup.Program.KeygroupMasterTranspose = fmt.Sprintf("%.6f", globalTranspose)
-		klog.V(2).Infof("Set global master transpose to %.2f", globalTranspose)
+	// Drum programs keep all pads, unused pads are left empty
+	if x.ProgramType != ProgramTypeDrum && filled < len(keyGroup.Program.Instruments.Instrument) {
+		keyGroup.Program.Instruments.Instrument = keyGroup.Program.Instruments.Instrument[:filled]
+		klog.V(2).Infof("Resized instrument array from 128 to %d actual instruments", filled)
+	}
+
+	// Pitch bend range, transpose, tuning and volume of the instrument
+	if exsFile.Params != nil {
+		convertTuning(exsFile.Name, exsFile.Params, &keyGroup.Program)
+	} else {
+		keyGroup.Program.KeygroupPitchBendRange = xpm.Normalized(defaultPitchBendSemitones / maxPitchBendSemitones)
 	}
 
 	// Use EXS file name (without extension) for the output XPM file
@@ -726,9 +800,171 @@ func (x *XPM) toXPM(exsFile *exs.EXS, destPath string) error {
 	if idx := strings.LastIndex(filename, "."); idx > 0 {
 		filename = filename[:idx]
 	}
+	// Catch programs the MPC would refuse before writing them. The warnings
+	// are left out: the converter overlaps keygroups itself to split layers
+	// and play round robin sets.
+	findings := keyGroup.Validate(destPath, x.Target)
+	for _, f := range findings {
+		if f.Severity == xpm.SeverityError {
+			klog.Warningf("%s: %s", exsFile.Name, f)
+		}
+	}
+	if xpm.HasErrors(findings) {
+		return fmt.Errorf("%s is not a valid program for %s", exsFile.Name, x.Target.Description)
+	}
 	return keyGroup.Save(destPath + "/" + filename + ".xpm")
 }
 
+// roundRobinGroups returns the IDs of the groups that play round robin with
+// at least one other group that has zones
+func roundRobinGroups(exsFile *exs.EXS, groupMap map[uint32]*exs.Group) map[uint32]bool {
+	sets, findings := exsFile.RoundRobinSets()
+	for _, f := range findings {
+		klog.Warningf("%s: round robin: %s", exsFile.Name, f.Message)
+	}
+	roundRobin := make(map[uint32]bool)
+	for _, set := range sets {
+		var ids []uint32
+		for _, m := range set.Members {
+			id := exsFile.Groups[m.Group].ID
+			if _, ok := groupMap[id]; ok {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) < 2 {
+			continue
+		}
+		klog.V(2).Infof("Round Robin detected: groups %s", set)
+		for _, id := range ids {
+			roundRobin[id] = true
+		}
+	}
+	return roundRobin
+}
+
+// sortZoneSets orders the zone sets of GetZonesByKeyRanges by the first of
+// their zones in the file, as the sets come in map order.
+func sortZoneSets(exsFile *exs.EXS, z []map[string][]*exs.Zone) {
+	index := make(map[*exs.Zone]int, len(exsFile.Zones))
+	for i, zone := range exsFile.Zones {
+		index[zone] = i
+	}
+	first := func(zoneMap map[string][]*exs.Zone) int {
+		n := len(exsFile.Zones)
+		for _, zones := range zoneMap {
+			for _, zone := range zones {
+				n = min(n, index[zone])
+			}
+		}
+		return n
+	}
+	sort.SliceStable(z, func(i, j int) bool {
+		return first(z[i]) < first(z[j])
+	})
+}
+
+// instrumentConflicts returns the settings a group writes to its drum pad
+// that differ between a and b.
+func instrumentConflicts(a, b *xpm.Instrument) []string {
+	settings := []struct {
+		name   string
+		differ bool
+	}{
+		{"volume", a.Volume != b.Volume},
+		{"mute group", a.MuteGroup != b.MuteGroup},
+		{"filter", a.Cutoff != b.Cutoff || a.Resonance != b.Resonance || a.FilterType != b.FilterType},
+		{"envelope", a.VolumeAttack != b.VolumeAttack || a.VolumeHold != b.VolumeHold || a.VolumeDecay != b.VolumeDecay ||
+			a.VolumeSustain != b.VolumeSustain || a.VolumeRelease != b.VolumeRelease},
+		{"trigger mode", a.TriggerMode != b.TriggerMode},
+		{"polyphony", a.Polyphony != b.Polyphony || a.Mono != b.Mono},
+		{"zone play", a.ZonePlay != b.ZonePlay},
+	}
+	var differ []string
+	for _, s := range settings {
+		if s.differ {
+			differ = append(differ, s.name)
+		}
+	}
+	return differ
+}
+
+// maxMuteGroups is the highest mute group of the MPC.
+const maxMuteGroups = 32
+
+// muteGroups returns the mute group, from 1, of the groups whose voices cut
+// each other: groups of the same exclusive class, and mono groups playing
+// more than one note, as Logic chokes an open hi-hat with the closed one
+// in the same group.
+func muteGroups(exsFile *exs.EXS, groupMap map[uint32]*exs.Group) map[uint32]int {
+	notes := make(map[uint32]map[int]bool)
+	for _, zone := range exsFile.Zones {
+		id := uint32(zone.GroupIndex)
+		if notes[id] == nil {
+			notes[id] = make(map[int]bool)
+		}
+		for note := int(zone.KeyLow); note <= int(zone.KeyHigh); note++ {
+			notes[id][note] = true
+		}
+	}
+
+	mute := make(map[uint32]int)
+	classes := make(map[int8]int)
+	next := 1
+	for _, g := range exsFile.Groups {
+		if _, ok := groupMap[g.ID]; !ok {
+			continue
+		}
+		var number int
+		switch {
+		case g.Exclusive > 0:
+			if classes[g.Exclusive] == 0 {
+				classes[g.Exclusive] = next
+				next++
+			}
+			number = classes[g.Exclusive]
+		case g.Polyphony == 1 && len(notes[g.ID]) > 1:
+			number = next
+			next++
+		default:
+			continue
+		}
+		if number > maxMuteGroups {
+			klog.Warningf("%s: group %q needs mute group %d, the MPC has %d", exsFile.Name, g.Name, number, maxMuteGroups)
+			continue
+		}
+		klog.V(2).Infof("Mute group %d: group %q (exclusive %d, polyphony %d)", number, g.Name, g.Exclusive, g.Polyphony)
+		mute[g.ID] = number
+	}
+	return mute
+}
+
+// setMuteTargets makes each drum pad in a mute group mute the other pads
+// of its group, the first four of them, and writes the mute groups to the
+// pad group map.
+func setMuteTargets(program *xpm.Program) {
+	instruments := program.Instruments.Instrument
+	for i := range instruments {
+		instrument := &instruments[i]
+		if instrument.MuteGroup == 0 || len(instrument.Layers.Layer) == 0 {
+			continue
+		}
+		if program.PadGroupMap != nil && i < len(program.PadGroupMap.PadGroup) {
+			program.PadGroupMap.PadGroup[i].Group = instrument.MuteGroup
+		}
+		var targets []int
+		for pad, other := range instruments {
+			if pad != i && other.MuteGroup == instrument.MuteGroup && len(other.Layers.Layer) > 0 {
+				targets = append(targets, pad+1)
+			}
+		}
+		targets = append(targets, 0, 0, 0, 0)
+		instrument.MuteTarget1 = targets[0]
+		instrument.MuteTarget2 = targets[1]
+		instrument.MuteTarget3 = targets[2]
+		instrument.MuteTarget4 = targets[3]
+	}
+}
+
 func Btoi(b bool) int {
 	if b {
 		return 1
@@ -736,7 +972,7 @@ func Btoi(b bool) int {
 	return 0
 }
 
-func convertGain(volumeDB float64) string {
+func convertGain(volumeDB float64) xpm.Gain {
 	if volumeDB > 6 {
 		volumeDB = 6
 	}
@@ -750,16 +986,16 @@ func convertGain(volumeDB float64) string {
 	*/
 	v := 12 + volumeDB
 	res := (1 - 0.353000) * v / 18
-	return fmt.Sprintf("%.6f", 0.353000+res)
-	//return fmt.Sprintf("%.6f", math.Pow(10, volumeDB/20))
+	return xpm.Gain(0.353000 + res)
+	//return xpm.GainFromDB(volumeDB)
 }
 
 func clamp(value, minimum, maximum float64) float64 {
 	return math.Max(minimum, math.Min(value, maximum))
 }
 
-func normalizeValue(value, minimum, maximum float64) string {
-	return fmt.Sprintf("%.6f", clamp(value, minimum, maximum)/maximum)
+func normalizeValue(value, minimum, maximum float64) xpm.Normalized {
+	return xpm.Normalized(clamp(value, minimum, maximum) / maximum)
 }
 
 // Constants for envelope time conversion (from ConvertWithMoss MPCKeygroupConstants)
@@ -773,18 +1009,25 @@ const (
 	DefaultSustainLevel = 1.0   // Default sustain level
 )
 
-// formatEnvTime converts EXS envelope time values to XPM normalized values using logarithmic scaling
-// EXS envelope times are in 0-127 range representing 0-10 seconds
+// formatEnvTime converts an envelope time in seconds to XPM normalized values using logarithmic scaling
 // XPM uses logarithmic time scaling: normalizedValue = ln(time/min) / ln(max/min)
 // Based on ConvertWithMoss normalizeLogarithmicEnvTimeValue
-func formatEnvTime(envTime float64) string {
-	if envTime < 0 {
-		return fmt.Sprintf("%.6f", normalizeLogarithmicEnvTimeValue(DefaultAttackTime, MinEnvTimeSeconds, MaxEnvTimeSeconds))
+func formatEnvTime(timeInSeconds float64) xpm.Normalized {
+	if timeInSeconds < 0 {
+		timeInSeconds = DefaultAttackTime
 	}
-	// Convert EXS 0-127 range to 0-10 seconds (EXS maximum time per step)
-	timeInSeconds := (envTime / 127.0) * 10.0
 	// Apply logarithmic normalization for MPC
-	return fmt.Sprintf("%.6f", normalizeLogarithmicEnvTimeValue(timeInSeconds, MinEnvTimeSeconds, MaxEnvTimeSeconds))
+	return xpm.Normalized(normalizeLogarithmicEnvTimeValue(timeInSeconds, MinEnvTimeSeconds, MaxEnvTimeSeconds))
+}
+
+// groupEnvTime converts a group envelope time (0-127) to seconds with the approximate EXS envelope curve
+func groupEnvTime(envTime int32) float64 {
+	return exs.ApproxEnvTimeSeconds(int16(clamp(float64(envTime), 0, 127)))
+}
+
+// groupEnvLevel converts a group envelope level (0-127) to 0-1
+func groupEnvLevel(envLevel int32) float64 {
+	return exs.EnvLevel(int16(clamp(float64(envLevel), 0, 127)))
 }
 
 // normalizeLogarithmicEnvTimeValue computes normalized logarithmic value between 0 and 1
@@ -806,46 +1049,142 @@ func normalizeLogarithmicEnvTimeValue(value, minimum, maximum float64) float64 {
 	return math.Log(value/minimum) / math.Log(maximum/minimum)
 }
 
-// formatEnvLevel converts EXS envelope level values to XPM normalized values (0-1)
-// Based on ConvertWithMoss logic where sustain levels are scaled from 0-127 to 0-1
-func formatEnvLevel(envLevel float64) string {
-	if envLevel < 0 {
-		envLevel = 0
+// formatEnvLevel formats an envelope level (0-1) as an XPM normalized value
+func formatEnvLevel(envLevel float64) xpm.Normalized {
+	return xpm.Normalized(clamp(envLevel, 0, 1))
+}
+
+// exsFilterTypes maps the EXS filter types to the MPC ones. The MPC has no
+// three pole low pass, LP18 gets the four pole one.
+var exsFilterTypes = map[int16]int{
+	exs.FilterLP24: xpm.FilterLow4,
+	exs.FilterLP18: xpm.FilterLow4,
+	exs.FilterLP12: xpm.FilterLow2,
+	exs.FilterLP6:  xpm.FilterLow1,
+	exs.FilterHP12: xpm.FilterHigh2,
+	exs.FilterBP12: xpm.FilterBand2,
+}
+
+// convertFilterType returns the MPC filter type of the EXS filter, Off if it
+// is switched off. Fat low passes keep their bass at high resonance, as the
+// Classic MPC filter does, and become one. The MPC filters have no drive,
+// so FilterDrive is lost. It returns false if the instrument does not say
+// whether the filter is on, leaving the filter of the template.
+func convertFilterType(params *exs.Params) (int, bool) {
+	on, ok := params.FilterSwitch()
+	if !ok {
+		return 0, false
+	}
+	if !on {
+		return xpm.FilterOff, true
+	}
+	filterType, ok := exsFilterTypes[params.FilterType]
+	if !ok {
+		klog.Warningf("Unknown EXS filter type %d, using a 2 pole low pass", params.FilterType)
+		return xpm.FilterLow2, true
 	}
-	if envLevel > 127 {
-		envLevel = 127
+	if params.FilterFat {
+		switch filterType {
+		case xpm.FilterLow4, xpm.FilterLow2, xpm.FilterLow1:
+			return xpm.FilterClassic, true
+		}
+	}
+	return filterType, true
+}
+
+// convertVoiceMode sets the voice mode, polyphony and glide of program. A
+// legato EXS instrument glides only between overlapping notes and keeps its
+// envelopes running, as the MPC does with PortamentoLegato. The MPC has no
+// unison, it is lost.
+func convertVoiceMode(name string, params *exs.Params, program *xpm.Program) {
+	switch params.MonoMode {
+	case exs.MonoOff:
+		program.Mono = false
+		if params.Voices > 0 {
+			program.ProgramPolyphony = int(params.Voices)
+		}
+	case exs.MonoOn, exs.MonoLegato:
+		program.Mono = true
+		program.ProgramPolyphony = 1
+		program.PortamentoLegato = xpm.Bool(params.MonoMode == exs.MonoLegato)
+	default:
+		klog.Warningf("%s: unknown voice mode %d, playing poly", name, params.MonoMode)
+	}
+	program.PortamentoTime = portamentoTime(params.GlideSeconds())
+	if params.Unison {
+		klog.Warningf("%s: the MPC has no unison, the instrument plays single voices", name)
+	}
+}
+
+// maxGlideSeconds is the longest EXS glide, at the end of its 0-5000 ms
+// range.
+const maxGlideSeconds = 5.0
+
+// portamentoTime converts an EXS glide time to the MPC PortamentoTime. Akai
+// documents no time for the portamento control, so the EXS glide range is
+// spread linearly over it: no glide is 0 and the longest glide 1, and a
+// glide keeps its position on the control.
+func portamentoTime(glideSeconds float64) xpm.Normalized {
+	return xpm.Normalized(clamp(glideSeconds/maxGlideSeconds, 0, 1))
+}
+
+// Ranges of the program tuning controls.
+const (
+	maxPitchBendSemitones     = 12.0 // at KeygroupPitchBendRange 1
+	maxTransposeSemitones     = 36.0 // at KeygroupMasterTranspose 0 and 1, 0.5 does not transpose
+	defaultPitchBendSemitones = 2.0  // of instruments without parameters
+	exsDefaultVolumeDB        = -6.0 // output volume of new EXS instruments
+	mpcDefaultVolumeDB        = -3.0 // volume of new MPC programs, 0.707946
+)
+
+// convertTuning sets the pitch bend range, transpose, tuning and volume of
+// program. The MPC bends as far down as up, an instrument that bends further
+// one way gets the larger range. The MPC stores the program volume as a
+// linear gain, xpm.Gain. A new EXS instrument plays at -6 dB and a new
+// program at -3 dB, so the volume keeps its distance from the default.
+func convertTuning(name string, params *exs.Params, program *xpm.Program) {
+	up, down := params.PitchBendSemitones()
+	bend := up
+	if up != down {
+		if down > bend {
+			bend = down
+		}
+		klog.Warningf("%s: pitch bend range is %d semitones up and %d down, the MPC bends %d both ways", name, up, down, bend)
 	}
-	// Convert from 0-127 range to 0-1 range
-	return fmt.Sprintf("%.6f", envLevel/127.0)
+	program.KeygroupPitchBendRange = xpm.Normalized(float64(bend) / maxPitchBendSemitones)
+	program.KeygroupMasterTranspose = xpm.Normalized(0.5 + float64(params.Transpose)/(2*maxTransposeSemitones))
+	program.TuneCoarse = int(params.CoarseTune)
+	program.TuneFine = int(params.FineTune)
+	program.Volume = xpm.GainFromDB(params.OutputVolumeDB() - exsDefaultVolumeDB + mpcDefaultVolumeDB)
 }
 
 // formatFilterCutoff converts EXS filter cutoff (0-127) to XPM normalized value (0-1)
-func formatFilterCutoff(cutoff float64) string {
+func formatFilterCutoff(cutoff float64) xpm.Normalized {
 	if cutoff < 0 {
 		cutoff = 0
 	}
 	if cutoff > 127 {
 		cutoff = 127
 	}
-	return fmt.Sprintf("%.6f", cutoff/127.0)
+	return xpm.Normalized(cutoff / 127.0)
 }
 
 // formatFilterResonance converts EXS filter resonance (0-127) to XPM normalized value (0-1)
-func formatFilterResonance(resonance float64) string {
+func formatFilterResonance(resonance float64) xpm.Normalized {
 	if resonance < 0 {
 		resonance = 0
 	}
 	if resonance > 127 {
 		resonance = 127
 	}
-	return fmt.Sprintf("%.6f", resonance/127.0)
+	return xpm.Normalized(resonance / 127.0)
 }
 
 // formatEnvelopeCurve converts EXS envelope curve values to XPM normalized curve values
 // EXS attack curves are typically in range -99 to +99 (signed)
 // XPM curves are 0.0 to 1.0 where 0.5 is linear
 // Based on ConvertWithMoss setEnvelopeCurveAttribute and EXS24Detector.createEnvelope
-func formatEnvelopeCurve(exsCurve int) string {
+func formatEnvelopeCurve(exsCurve int) xpm.Normalized {
 	// EXS curves can be stored as signed values or with special encoding
 	// If value >= 0xFF00, it represents a negative value: v = v - 0xFF00 - 0x100
 	if exsCurve >= 0xFF00 {
@@ -855,18 +1194,18 @@ func formatEnvelopeCurve(exsCurve int) string {
 	slopeValue := clamp(float64(exsCurve)/99.0, -1.0, 1.0)
 	// Convert to XPM 0..1 range where 0.5 is linear
 	curveValue := clamp((slopeValue+1.0)/2.0, 0.0, 1.0)
-	return fmt.Sprintf("%.6f", curveValue)
+	return xpm.Normalized(curveValue)
 }
 
 // getDefaultEnvelopeCurve returns the default envelope curve value (0.5 = linear)
-func getDefaultEnvelopeCurve() string {
-	return "0.500000"
+func getDefaultEnvelopeCurve() xpm.Normalized {
+	return 0.5
 }
 
 // convertVolumeDbToLinear converts EXS zone volume from dB (-60 to +12) to linear gain (0.0 to ~2.0)
 // EXS zone volume range: -60 dB (silent) to +12 dB (boost)
 // XPM layer volume: linear gain multiplier where 1.0 = unity gain
-func convertVolumeDbToLinear(volumeDB int) string {
+func convertVolumeDbToLinear(volumeDB int) xpm.Gain {
 	// Clamp to EXS valid range
 	if volumeDB < -60 {
 		volumeDB = -60
@@ -875,14 +1214,13 @@ func convertVolumeDbToLinear(volumeDB int) string {
 		volumeDB = 12
 	}
 	// Convert dB to linear: gain = 10^(dB/20)
-	linear := math.Pow(10.0, float64(volumeDB)/20.0)
-	return fmt.Sprintf("%.6f", linear)
+	return xpm.GainFromDB(float64(volumeDB))
 }
 
 // convertPanToNormalized converts EXS zone pan from (-64 to +63) to XPM normalized (0.0 to 1.0)
 // EXS pan range: -64 (hard left) to +63 (hard right), 0 = center
 // XPM pan range: 0.0 (left) to 1.0 (right), 0.5 = center
-func convertPanToNormalized(pan int) string {
+func convertPanToNormalized(pan int) xpm.Normalized {
 	// Clamp to EXS valid range
 	if pan < -64 {
 		pan = -64
@@ -892,26 +1230,17 @@ func convertPanToNormalized(pan int) string {
 	}
 	// Convert to 0.0-1.0 range where 0.5 is center
 	// pan = -64 -> 0.0, pan = 0 -> 0.5, pan = 63 -> ~0.992
-	normalized := (float64(pan) + 64.0) / 127.0
-	return fmt.Sprintf("%.6f", normalized)
+	return xpm.Normalized((float64(pan) + 64.0) / 127.0)
 }
 
 // Phase 2 Conversion Functions
 
 // convertScaleToKeyTrack converts EXS zone scale to XPM layer KeyTrack
 // EXS Scale: -100 to +100 (percentage of key tracking), typically 0-100 range
-// XPM KeyTrack: 0.0 to 1.0 (1.0 = full tracking, 0.0 = no tracking/fixed pitch)
-func convertScaleToKeyTrack(scale int) string {
-	// EXS scale is typically 0-100, where 100 = full key tracking
-	// Normalize to 0.0-1.0 range
-	if scale < 0 {
-		scale = 0
-	}
-	if scale > 100 {
-		scale = 100
-	}
-	keyTrack := float64(scale) / 100.0
-	return fmt.Sprintf("%.6f", keyTrack)
+// XPM KeyTrack: True/False, the MPC has no partial key tracking, so any
+// positive scale tracks the key
+func convertScaleToKeyTrack(scale int) xpm.Bool {
+	return scale > 0
 }
 
 // convertOutputToAudioRoute converts EXS output number to XPM AudioRoute int
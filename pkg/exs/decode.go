@@ -27,59 +27,60 @@ const (
 	plistChunk   = uint32(0x0B)
 )
 
+// chunkHeaderSize is the size of the header preceding every chunk payload.
+const chunkHeaderSize = 84
+
+// Smallest payload sizes the decoder accepts. Files written by EXS24 mk1 and
+// mk2 on PowerPC use shorter chunks than current versions of Logic; fields
+// past the end of a chunk read as zero.
+const (
+	minZonePayloadSize   = 96  // up to and including the sample index
+	minSamplePayloadSize = 336 // without the file name, which mk1 kept in the name
+)
+
 // ============================================================================
 // Constructors
 // ============================================================================
 
 // NewFromFile creates a new EXS from a file.
 func NewFromFile(fileName string) (*EXS, error) {
-	file, err := os.ReadFile(fileName)
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
 	if err != nil {
 		return nil, err
 	}
 	// basename of filename
 	name := strings.TrimSuffix(path.Base(fileName), ".exs")
-	return NewFromReader(bytes.NewReader(file), name)
+	exs, err := NewFromReaderAt(file, info.Size(), name)
+	if err != nil {
+		return nil, err
+	}
+	exs.FilePath = fileName
+	return exs, nil
 }
 
 // NewFromReader creates a new EXS from a reader.
 func NewFromReader(r *bytes.Reader, name string) (*EXS, error) {
+	return NewFromReaderAt(r, r.Size(), name)
+}
+
+// NewFromReaderAt creates a new EXS from the first size bytes of r.
+// Chunks are read one at a time and checked against size, so a corrupt file
+// fails with a *ChunkError carrying the offset and index of the bad chunk.
+func NewFromReaderAt(r io.ReaderAt, size int64, name string) (*EXS, error) {
 	exs := &EXS{
 		Name: name,
+		Size: int(size),
 	}
-	header, err := exs.readHeader(r)
+	err := exs.readHeader(r)
 	if err != nil {
 		return nil, err
 	}
-	if string(header.Magic[:]) != "SOBT" &&
-		string(header.Magic[:]) != "SOBJ" &&
-		string(header.Magic[:]) != "TBOS" &&
-		string(header.Magic[:]) != "JBOS" {
-		return nil, errors.New("not an exs file")
-	}
 	klog.V(2).Infof(">>>>>>> %+s <<<<<<<<<", exs.Name)
-	klog.V(5).Infof("Magic: %s", header.Magic)
-	if bytes.Equal(header.Magic[:], []byte("SOBT")) {
-		exs.BigEndian = true
-	}
-	if bytes.Equal(header.Magic[:], []byte("SOBJ")) {
-		exs.BigEndian = true
-	}
-	header, err = exs.readHeader(r)
-	if err != nil {
-		return nil, err
-	}
-
-	// determine if the file is size expanded
-	// by checking the size of the header
-	if header.Size > 0x8000 {
-		klog.V(5).Infof("Size expanded file")
-		exs.IsSizeExpanded = true
-	}
-	exs.Size, err = exs.readSize(r)
-	if err != nil {
-		return nil, err
-	}
 	klog.V(5).Infof("Size: %d", exs.Size)
 
 	err = exs.readChunks(r)
@@ -93,31 +94,72 @@ func NewFromReader(r *bytes.Reader, name string) (*EXS, error) {
 // EXS Methods - Binary Readers (internal)
 // ============================================================================
 
-// readSize reads the size of the exs file.
-func (exs *EXS) readSize(r *bytes.Reader) (int, error) {
-	// get the size of the reader
-	size, err := r.Seek(0, os.SEEK_END)
-	if err != nil {
-		return 0, err
+// byteOrder returns the byte order of the exs file.
+func (exs *EXS) byteOrder() binary.ByteOrder {
+	if exs.BigEndian {
+		return binary.BigEndian
 	}
-	return int(size), nil
+	return binary.LittleEndian
 }
 
-// readHeader reads the header of the exs file.
-func (exs *EXS) readHeader(r *bytes.Reader) (*ExsHeader, error) {
+// readHeader reads the header of the exs file and detects its endianness.
+func (exs *EXS) readHeader(r io.ReaderAt) error {
+	data, err := readAt(r, 0, chunkHeaderSize, int64(exs.Size))
+	if err != nil {
+		return &ChunkError{Err: err}
+	}
 	var header ExsHeader
-	if !exs.BigEndian {
-		err := binary.Read(r, binary.LittleEndian, &header)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(r, binary.BigEndian, &header)
-		if err != nil {
-			return nil, err
+	// the magic is a byte array, so it can be read before the byte order is known
+	copy(header.Magic[:], data[16:20])
+	switch string(header.Magic[:]) {
+	case "SOBT", "SOBJ":
+		exs.BigEndian = true
+	case "TBOS", "JBOS":
+	default:
+		return &ChunkError{Err: ErrBadMagic}
+	}
+	klog.V(5).Infof("Magic: %s", header.Magic)
+	err = exs.decodeChunk(data, &header)
+	if err != nil {
+		return &ChunkError{Err: err}
+	}
+
+	// determine if the file is size expanded
+	// by checking the size of the header
+	if exs.byteOrder().Uint32(data[24:28]) > 0x8000 {
+		klog.V(5).Infof("Size expanded file")
+		exs.IsSizeExpanded = true
+	}
+	return nil
+}
+
+// readAt reads n bytes at off from r, failing with ErrTruncatedChunk if the
+// read would go past size or r runs out of data.
+func readAt(r io.ReaderAt, off, n, size int64) ([]byte, error) {
+	if off < 0 || n < 0 || off+n > size {
+		return nil, ErrTruncatedChunk
+	}
+	data := make([]byte, n)
+	read, err := r.ReadAt(data, off)
+	if read < len(data) {
+		if err == nil || errors.Is(err, io.EOF) {
+			return nil, ErrTruncatedChunk
 		}
+		return nil, err
 	}
-	return &header, nil
+	return data, nil
+}
+
+// decodeChunk decodes data into v using the byte order of the exs file.
+// Chunks written by older versions can be shorter than v; the missing tail
+// is read as zeros.
+func (exs *EXS) decodeChunk(data []byte, v interface{}) error {
+	if n := binary.Size(v); len(data) < n {
+		padded := make([]byte, n)
+		copy(padded, data)
+		data = padded
+	}
+	return binary.Read(bytes.NewReader(data), exs.byteOrder(), v)
 }
 
 // ============================================================================
@@ -338,32 +380,42 @@ func (exs *EXS) IsDrumProgram() bool {
 	return false
 }
 
-// readChunks
-func (exs *EXS) readChunks(r *bytes.Reader) error {
-	i := 0
-	// read until end of reader
-	for i+84 < exs.Size {
-		r.Seek(int64(i), io.SeekStart)
-		header, err := exs.readChunkHeader(r)
+// readChunks reads all chunks following the file header. Trailing bytes too
+// short for a chunk header are ignored, as older versions of the decoder
+// did; a chunk whose header fits but whose payload does not is truncated.
+func (exs *EXS) readChunks(r io.ReaderAt) error {
+	size := int64(exs.Size)
+	offset := int64(0)
+	// read until no chunk header fits
+	for index := 0; offset+chunkHeaderSize <= size; index++ {
+		header, err := exs.readChunkHeader(r, offset)
 		if err != nil {
-			return err
+			return &ChunkError{Err: err, Offset: offset, Index: index}
 		}
 		chunkType := header.Signature & 0x0F000000 >> 24
+		chunkErr := func(err error) error {
+			return &ChunkError{Err: err, Offset: offset, Index: index, Type: chunkType, Size: header.Size}
+		}
+		data, err := readAt(r, offset, chunkHeaderSize+int64(header.Size), size)
+		if err != nil {
+			return chunkErr(err)
+		}
 		switch chunkType {
 		case headerChunk:
 			klog.V(5).Infof("Chunk: %d (exs instrument chunk), size: %d", chunkType, header.Size)
-			instrument, err := exs.readInstrument(r, i)
+			instrument, err := exs.readInstrument(data)
 			if err != nil {
-				return err
+				return chunkErr(err)
 			}
 			exs.Instrument = instrument
+			exs.Raw = data
 		case zoneChunk:
-			if header.Size < 110 {
-				return errors.New("invalid zone chunk size")
+			if header.Size < minZonePayloadSize {
+				return chunkErr(ErrUnknownChunkSize)
 			}
-			zone, err := exs.readZone(r, i)
+			zone, err := exs.readZone(data)
 			if err != nil {
-				return err
+				return chunkErr(err)
 			}
 			exs.Zones = append(exs.Zones, zone)
 			klog.V(2).Infof("Zone: %s, size: %d, keyLow: %d, keyHigh: %d, key: %d, sample: %d", zone.Name, header.Size, zone.KeyLow, zone.KeyHigh, zone.Key, zone.SampleIndex)
@@ -371,43 +423,50 @@ func (exs *EXS) readChunks(r *bytes.Reader) error {
 			klog.V(5).Infof("Exs chunk type: %d (group), size: %d",
 				chunkType,
 				header.Size)
-			group, err := exs.readGroup(r, i)
+			group, err := exs.readGroup(data)
 			if err != nil {
-				return err
+				return chunkErr(err)
 			}
 			exs.Groups = append(exs.Groups, group)
 		case sampleChunk:
 			klog.V(5).Infof("Exs chunk type: %d (sample), size: %d", chunkType, header.Size)
-			if header.Size != 336 && header.Size != 592 && header.Size != 600 {
-				return errors.New("invalid sample chunk size")
+			if header.Size != minSamplePayloadSize && header.Size != 592 && header.Size != 600 {
+				return chunkErr(ErrUnknownChunkSize)
 			}
-			sample, err := exs.readSample(r, i)
+			sample, err := exs.readSample(data)
 			if err != nil {
-				return err
+				return chunkErr(err)
 			}
 			exs.Samples = append(exs.Samples, sample)
 		case optionsChunk:
 			klog.V(5).Infof("Exs chunk type: %d (options), size: %d", chunkType, header.Size)
-			exsParams, err := exs.readParams(r, i)
+			exsParams, err := exs.readParams(data)
 			if err != nil {
-				return err
+				return chunkErr(err)
 			}
 			params := NewParamsFromExsParams(exsParams)
+			err = exs.readExtendedParams(data, params)
+			if err != nil {
+				return chunkErr(err)
+			}
+			params.Raw = data
 			exs.Params = params
 		case plistChunk:
-			klog.V(5).Infof("Exs chunk type: %d (binary plist), size: %d - skipping", chunkType, header.Size)
+			klog.V(5).Infof("Exs chunk type: %d (binary plist), size: %d", chunkType, header.Size)
+			err := exs.readPlist(data)
+			if err != nil {
+				// the plist only carries extras, the instrument is usable without it
+				klog.Warningf("%s: %v", exs.Name, chunkErr(err))
+			}
+			exs.Extra = append(exs.Extra, RawChunk{Type: chunkType, Offset: offset, Data: data})
 		default:
 			klog.V(5).Infof("Exs chunk type: %d (unknown)", chunkType)
+			exs.Extra = append(exs.Extra, RawChunk{Type: chunkType, Offset: offset, Data: data})
 		}
-		i = i + int(header.Size) + 84
+		offset += int64(len(data))
 	}
-	err := exs.ReadSequences()
-	if err != nil {
-		return err
-	}
-	err = exs.ConvertSeqNumbers()
-	if err != nil {
-		return err
+	if offset < size {
+		klog.V(2).Infof("%s: ignoring %d trailing bytes at offset %d", exs.Name, size-offset, offset)
 	}
 
 	klog.V(2).Infof("Exs %s contains %d groups, %d zones, %d samples", exs.Name, len(exs.Groups), len(exs.Zones), len(exs.Samples))
@@ -415,40 +474,26 @@ func (exs *EXS) readChunks(r *bytes.Reader) error {
 	return nil
 }
 
-// readChunkHeader reads the header of the exs file.
-func (exs *EXS) readChunkHeader(r *bytes.Reader) (*ExsChunkHeader, error) {
+// readChunkHeader reads the header of the chunk at offset.
+func (exs *EXS) readChunkHeader(r io.ReaderAt, offset int64) (*ExsChunkHeader, error) {
 	var header ExsChunkHeader
-	if !exs.BigEndian {
-		err := binary.Read(r, binary.LittleEndian, &header)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(r, binary.BigEndian, &header)
-		if err != nil {
-			return nil, err
-		}
+	data, err := readAt(r, offset, int64(binary.Size(header)), int64(exs.Size))
+	if err != nil {
+		return nil, err
+	}
+	err = exs.decodeChunk(data, &header)
+	if err != nil {
+		return nil, err
 	}
 	return &header, nil
 }
 
-func (exs *EXS) readZone(reader *bytes.Reader, pos int) (*Zone, error) {
-	_, err := reader.Seek(int64(pos), io.SeekStart)
+func (exs *EXS) readZone(data []byte) (*Zone, error) {
+	var exsZone ExsZone
+	err := exs.decodeChunk(data, &exsZone)
 	if err != nil {
 		return nil, err
 	}
-	var exsZone ExsZone
-	if exs.BigEndian {
-		err := binary.Read(reader, binary.BigEndian, &exsZone)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(reader, binary.LittleEndian, &exsZone)
-		if err != nil {
-			return nil, err
-		}
-	}
 	zone := &Zone{
 		ExsZone:         exsZone,
 		Name:            getString64(exsZone.Name),
@@ -461,101 +506,82 @@ func (exs *EXS) readZone(reader *bytes.Reader, pos int) (*Zone, error) {
 		LoopEndRelease:  exsZone.LoopOpts&0x04 != 0,
 		PlayMode:        exsZone.PlayMode,
 		HasOutput:       exsZone.Opts&0x40 != 0,
+		Raw:             data,
 	}
 	return zone, nil
 }
 
-func (exs *EXS) readGroup(reader *bytes.Reader, pos int) (*Group, error) {
-	_, err := reader.Seek(int64(pos), io.SeekStart)
+func (exs *EXS) readGroup(data []byte) (*Group, error) {
+	var exsGroup ExsGroup
+	err := exs.decodeChunk(data, &exsGroup)
 	if err != nil {
 		return nil, err
 	}
-	var exsGroup ExsGroup
-	if exs.BigEndian {
-		err := binary.Read(reader, binary.BigEndian, &exsGroup)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(reader, binary.LittleEndian, &exsGroup)
-		if err != nil {
-			return nil, err
-		}
-	}
 	group := &Group{
 		ExsGroup: exsGroup,
 		Name:     getString64(exsGroup.Name),
 		Decay:    exsGroup.Decay&0x40 != 0,
+		Selector: newSelector(&exsGroup),
+		Raw:      data,
 	}
 	klog.V(5).Infof("Group: name: %s", string(group.Name[:]))
 	return group, nil
 }
 
 // readSample reads the sample data.
-func (exs *EXS) readSample(reader *bytes.Reader, pos int) (*Sample, error) {
-	_, err := reader.Seek(int64(pos), io.SeekStart)
+func (exs *EXS) readSample(data []byte) (*Sample, error) {
+	var sample ExsSample
+	err := exs.decodeChunk(data, &sample)
 	if err != nil {
 		return nil, err
 	}
-	var sample ExsSample
-	if exs.BigEndian {
-		err := binary.Read(reader, binary.BigEndian, &sample)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(reader, binary.LittleEndian, &sample)
-		if err != nil {
-			return nil, err
-		}
-	}
 	klog.V(5).Infof("Sample: name: %s, filename: %s, path: %s", string(sample.Name[:]), string(sample.FileName[:]), string(sample.Path[:]))
 	return &Sample{
 		ExsSample: sample,
 		Name:      getString64(sample.Name),
 		FileName:  getString256(sample.FileName),
 		Path:      getString256(sample.Path),
+		Raw:       data,
 	}, nil
 }
 
-func (exs *EXS) readParams(reader *bytes.Reader, pos int) (*ExsParams, error) {
-	_, err := reader.Seek(int64(pos), io.SeekStart)
+func (exs *EXS) readParams(data []byte) (*ExsParams, error) {
+	var params ExsParams
+	err := exs.decodeChunk(data, &params)
 	if err != nil {
 		return nil, err
 	}
-	var params ExsParams
-	if exs.BigEndian {
-		err := binary.Read(reader, binary.BigEndian, &params)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(reader, binary.LittleEndian, &params)
-		if err != nil {
-			return nil, err
-		}
-	}
 	klog.V(5).Infof("Params name: %s,  %+v", string(params.Name[:]), params)
 	return &params, nil
 }
 
-func (exs *EXS) readInstrument(reader *bytes.Reader, pos int) (*ExsInstrument, error) {
-	_, err := reader.Seek(int64(pos), io.SeekStart)
+// readExtendedParams adds the extended parameter table of large options
+// chunks to params.Unknown.
+func (exs *EXS) readExtendedParams(data []byte, params *Params) error {
+	start := binary.Size(ExsParams{})
+	var extended ExsParamsExtended
+	if len(data) < start+binary.Size(extended) {
+		return nil
+	}
+	err := exs.decodeChunk(data[start:], &extended)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var instrument ExsInstrument
-	if exs.BigEndian {
-		err := binary.Read(reader, binary.BigEndian, &instrument)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := binary.Read(reader, binary.LittleEndian, &instrument)
-		if err != nil {
-			return nil, err
+	for _, p := range extended.Params {
+		if p.Key != 0 {
+			params.Unknown = append(params.Unknown, Param{Key: p.Key, Value: p.Value, Extended: true})
 		}
 	}
+	return nil
+}
+
+// readInstrument reads the instrument counts from the header chunk payload.
+func (exs *EXS) readInstrument(data []byte) (*ExsInstrument, error) {
+	var instrument ExsInstrument
+	err := exs.decodeChunk(data[chunkHeaderSize:], &instrument)
+	if err != nil {
+		return nil, err
+	}
 	klog.V(5).Infof("Instrument: zones: %d, groups: %d, samples: %d", instrument.NumZones, instrument.NumGroups, instrument.NumSamples)
 	return &instrument, nil
 }
@@ -567,262 +593,19 @@ func NewParamsFromExsParams(exsParams *ExsParams) *Params {
 	for i < 100 {
 		key := exsParams.Keys[i]
 		value := exsParams.Values[i]
-		switch key {
-		case 7:
-			params.OutputVolume = value
-		case 8:
-			params.KeyScale = value
-		case 3:
-			params.PitchBendUp = value
-		case 4:
-			params.PitchBendDown = value
-		case 10:
-			params.MonoMode = value
-		case 5:
-			params.Voices = value
-		case 171:
-			params.Unison = value != 0 // 0:off 1:on
-		case 45:
-			params.Transpose = value
-		case 14:
-			params.CoarseTune = value
-		case 15:
-			params.FineTune = value
-		case 20:
-			params.GlideTime = value
-		case 44:
-			params.FilterOn = value != 0 // 0:off 1:on
-		case 46:
-			params.FilterViaKey = value
-		case 60:
-			params.Lfo1DecayDelay = value
-		case 61:
-			params.Lfo1Rate = value
-		case 62:
-			params.Lfo1Waveform = value
-		case 63:
-			params.Lfo2Rate = value
-		case 64:
-			params.Lfo2Waveform = value
-		case 72:
-			params.Pitcher = value
-		case 73:
-			params.PitcherViaVel = value
-		case 75:
-			params.FilterDrive = value
-		case 76:
-			params.Env1Attack = value
-		case 77:
-			params.Env1AttackViaVel = value
-		case 78:
-			params.Env1Decay = value
-		case 79:
-			params.Env1Sustain = value
-		case 80:
-			params.Env1Release = value
-		case 81:
-			params.Env2Sustain = value
-		case 82:
-			params.Env2Attack = value
-		case 83:
-			params.Env2AttackViaVel = value
-		case 84:
-			params.Env2Decay = value
-		case 85:
-			params.Env2Release = value
-		case 89:
-			params.LevelViaVel = value
-		case 90:
-			params.LevelFixed = value
-		case 91:
-			params.TimeCurve = value
-		case 92:
-			params.TimeVia = value
-		case 95:
-			params.VelocityOffset = value
-		case 97:
-			params.VelocityXFade = value
-		case 98:
-			params.RandomDetune = value
-		case 163:
-			params.SampleSelectRandom = value
-		case 164:
-			params.VelocityRandom = value
-		case 165:
-			params.VelocityXFadeType = value
-		case 166:
-			params.CoarseTune = value
-		case 167:
-			params.Lfo3Rate = value
-		case 170:
-			params.FilterFat = value != 0 // 0:off 1:on
-		case 172:
-			params.HoldVia = value
-		case 173:
-			params.Destination[0] = value
-		case 174:
-			params.Source[0] = value
-		case 175:
-			params.Via[0] = value
-		case 176:
-			params.Amount[0] = value
-		case 177:
-			params.AmountVia[0] = value
-		case 178:
-			params.InvertVia[0] = value != 0
-		case 179:
-			params.Destination[1] = value
-		case 180:
-			params.Source[1] = value
-		case 181:
-			params.Via[1] = value
-		case 182:
-			params.Amount[1] = value
-		case 183:
-			params.AmountVia[1] = value
-		case 184:
-			params.InvertVia[1] = value != 0
-		case 185:
-			params.Destination[2] = value
-		case 186:
-			params.Source[2] = value
-		case 187:
-			params.Via[2] = value
-		case 188:
-			params.Amount[2] = value
-		case 189:
-			params.AmountVia[2] = value
-		case 190:
-			params.InvertVia[2] = value != 0
-		case 191:
-			params.Destination[3] = value
-		case 192:
-			params.Source[3] = value
-		case 193:
-			params.Via[3] = value
-		case 194:
-			params.Amount[3] = value
-		case 195:
-			params.AmountVia[3] = value
-		case 196:
-			params.InvertVia[3] = value != 0
-		case 197:
-			params.Destination[4] = value
-		case 198:
-			params.Source[4] = value
-		case 199:
-			params.Via[4] = value
-		case 200:
-			params.Amount[4] = value
-		case 201:
-			params.AmountVia[4] = value
-		case 202:
-			params.InvertVia[4] = value != 0
-		case 203:
-			params.Destination[5] = value
-		case 204:
-			params.Source[5] = value
-		case 205:
-			params.Via[5] = value
-		case 206:
-			params.Amount[5] = value
-		case 207:
-			params.AmountVia[5] = value
-		case 208:
-			params.InvertVia[5] = value != 0
-		case 209:
-			params.Destination[6] = value
-		case 210:
-			params.Source[6] = value
-		case 211:
-			params.Via[6] = value
-		case 212:
-			params.Amount[6] = value
-		case 213:
-			params.AmountVia[6] = value
-		case 214:
-			params.InvertVia[6] = value != 0
-		case 215:
-			params.Destination[7] = value
-		case 216:
-			params.Source[7] = value
-		case 217:
-			params.Via[7] = value
-		case 218:
-			params.Amount[7] = value
-		case 219:
-			params.AmountVia[7] = value
-		case 220:
-			params.InvertVia[7] = value != 0
-		case 221:
-			params.Destination[8] = value
-		case 222:
-			params.Source[8] = value
-		case 223:
-			params.Via[8] = value
-		case 224:
-			params.Amount[8] = value
-		case 225:
-			params.AmountVia[8] = value
-		case 226:
-			params.InvertVia[8] = value != 0
-		case 227:
-			params.Destination[9] = value
-		case 228:
-			params.Source[9] = value
-		case 229:
-			params.Via[9] = value
-		case 230:
-			params.Amount[9] = value
-		case 231:
-			params.AmountVia[9] = value
-		case 232:
-			params.InvertVia[9] = value != 0
-		case 233:
-			params.Invert[0] = value != 0
-		case 234:
-			params.Invert[1] = value != 0
-		case 235:
-			params.Invert[2] = value != 0
-		case 236:
-			params.Invert[3] = value != 0
-		case 237:
-			params.Invert[4] = value != 0
-		case 238:
-			params.Invert[5] = value != 0
-		case 239:
-			params.Invert[6] = value != 0
-		case 240:
-			params.Invert[7] = value != 0
-		case 241:
-			params.Invert[8] = value != 0
-		case 242:
-			params.Invert[9] = value != 0
-		case 243:
-			params.FilterType = value
-		case 244:
-			params.Bypass[0] = value != 0
-		case 245:
-			params.Bypass[1] = value != 0
-		case 246:
-			params.Bypass[2] = value != 0
-		case 247:
-			params.Bypass[3] = value != 0
-		case 248:
-			params.Bypass[4] = value != 0
-		case 249:
-			params.Bypass[5] = value != 0
-		case 250:
-			params.Bypass[6] = value != 0
-		case 251:
-			params.Bypass[7] = value != 0
-		case 252:
-			params.Bypass[8] = value != 0
-		case 253:
-			params.Bypass[9] = value != 0
-
+		if key != 0 {
+			params.Keys = append(params.Keys, key)
+		}
+		switch field := params.field(key).(type) {
+		case *int16:
+			*field = value
+		case *bool:
+			*field = value != 0 // 0:off 1:on
 		default:
 			klog.V(5).Infof("unknown parameter %d", i)
+			if key != 0 {
+				params.Unknown = append(params.Unknown, Param{Key: uint16(key), Value: value})
+			}
 		}
 
 		i++
@@ -830,3 +613,263 @@ func NewParamsFromExsParams(exsParams *ExsParams) *Params {
 	//klog.Infof("params: %+v", params)
 	return params
 }
+
+// field returns a pointer to the *int16 or *bool field stored under key, or
+// nil if the key is unknown.
+func (params *Params) field(key uint8) interface{} {
+	switch key {
+	case 7:
+		return &params.OutputVolume
+	case 8:
+		return &params.KeyScale
+	case 3:
+		return &params.PitchBendUp
+	case 4:
+		return &params.PitchBendDown
+	case 10:
+		return &params.MonoMode
+	case 5:
+		return &params.Voices
+	case 171:
+		return &params.Unison // 0:off 1:on
+	case 45:
+		return &params.Transpose
+	case 14:
+		return &params.CoarseTune
+	case 15:
+		return &params.FineTune
+	case 20:
+		return &params.GlideTime
+	case 44:
+		return &params.FilterOn // 0:off 1:on
+	case 46:
+		return &params.FilterViaKey
+	case 60:
+		return &params.Lfo1DecayDelay
+	case 61:
+		return &params.Lfo1Rate
+	case 62:
+		return &params.Lfo1Waveform
+	case 63:
+		return &params.Lfo2Rate
+	case 64:
+		return &params.Lfo2Waveform
+	case 72:
+		return &params.Pitcher
+	case 73:
+		return &params.PitcherViaVel
+	case 75:
+		return &params.FilterDrive
+	case 76:
+		return &params.Env1Attack
+	case 77:
+		return &params.Env1AttackViaVel
+	case 78:
+		return &params.Env1Decay
+	case 79:
+		return &params.Env1Sustain
+	case 80:
+		return &params.Env1Release
+	case 81:
+		return &params.Env2Sustain
+	case 82:
+		return &params.Env2Attack
+	case 83:
+		return &params.Env2AttackViaVel
+	case 84:
+		return &params.Env2Decay
+	case 85:
+		return &params.Env2Release
+	case 89:
+		return &params.LevelViaVel
+	case 90:
+		return &params.LevelFixed
+	case 91:
+		return &params.TimeCurve
+	case 92:
+		return &params.TimeVia
+	case 95:
+		return &params.VelocityOffset
+	case 97:
+		return &params.VelocityXFade
+	case 98:
+		return &params.RandomDetune
+	case 163:
+		return &params.SampleSelectRandom
+	case 164:
+		return &params.VelocityRandom
+	case 165:
+		return &params.VelocityXFadeType
+	case 166:
+		return &params.CoarseTuneRemote
+	case 167:
+		return &params.Lfo3Rate
+	case 170:
+		return &params.FilterFat // 0:off 1:on
+	case 172:
+		return &params.HoldVia
+	case 173:
+		return &params.Destination[0]
+	case 174:
+		return &params.Source[0]
+	case 175:
+		return &params.Via[0]
+	case 176:
+		return &params.Amount[0]
+	case 177:
+		return &params.AmountVia[0]
+	case 178:
+		return &params.InvertVia[0]
+	case 179:
+		return &params.Destination[1]
+	case 180:
+		return &params.Source[1]
+	case 181:
+		return &params.Via[1]
+	case 182:
+		return &params.Amount[1]
+	case 183:
+		return &params.AmountVia[1]
+	case 184:
+		return &params.InvertVia[1]
+	case 185:
+		return &params.Destination[2]
+	case 186:
+		return &params.Source[2]
+	case 187:
+		return &params.Via[2]
+	case 188:
+		return &params.Amount[2]
+	case 189:
+		return &params.AmountVia[2]
+	case 190:
+		return &params.InvertVia[2]
+	case 191:
+		return &params.Destination[3]
+	case 192:
+		return &params.Source[3]
+	case 193:
+		return &params.Via[3]
+	case 194:
+		return &params.Amount[3]
+	case 195:
+		return &params.AmountVia[3]
+	case 196:
+		return &params.InvertVia[3]
+	case 197:
+		return &params.Destination[4]
+	case 198:
+		return &params.Source[4]
+	case 199:
+		return &params.Via[4]
+	case 200:
+		return &params.Amount[4]
+	case 201:
+		return &params.AmountVia[4]
+	case 202:
+		return &params.InvertVia[4]
+	case 203:
+		return &params.Destination[5]
+	case 204:
+		return &params.Source[5]
+	case 205:
+		return &params.Via[5]
+	case 206:
+		return &params.Amount[5]
+	case 207:
+		return &params.AmountVia[5]
+	case 208:
+		return &params.InvertVia[5]
+	case 209:
+		return &params.Destination[6]
+	case 210:
+		return &params.Source[6]
+	case 211:
+		return &params.Via[6]
+	case 212:
+		return &params.Amount[6]
+	case 213:
+		return &params.AmountVia[6]
+	case 214:
+		return &params.InvertVia[6]
+	case 215:
+		return &params.Destination[7]
+	case 216:
+		return &params.Source[7]
+	case 217:
+		return &params.Via[7]
+	case 218:
+		return &params.Amount[7]
+	case 219:
+		return &params.AmountVia[7]
+	case 220:
+		return &params.InvertVia[7]
+	case 221:
+		return &params.Destination[8]
+	case 222:
+		return &params.Source[8]
+	case 223:
+		return &params.Via[8]
+	case 224:
+		return &params.Amount[8]
+	case 225:
+		return &params.AmountVia[8]
+	case 226:
+		return &params.InvertVia[8]
+	case 227:
+		return &params.Destination[9]
+	case 228:
+		return &params.Source[9]
+	case 229:
+		return &params.Via[9]
+	case 230:
+		return &params.Amount[9]
+	case 231:
+		return &params.AmountVia[9]
+	case 232:
+		return &params.InvertVia[9]
+	case 233:
+		return &params.Invert[0]
+	case 234:
+		return &params.Invert[1]
+	case 235:
+		return &params.Invert[2]
+	case 236:
+		return &params.Invert[3]
+	case 237:
+		return &params.Invert[4]
+	case 238:
+		return &params.Invert[5]
+	case 239:
+		return &params.Invert[6]
+	case 240:
+		return &params.Invert[7]
+	case 241:
+		return &params.Invert[8]
+	case 242:
+		return &params.Invert[9]
+	case 243:
+		return &params.FilterType
+	case 244:
+		return &params.Bypass[0]
+	case 245:
+		return &params.Bypass[1]
+	case 246:
+		return &params.Bypass[2]
+	case 247:
+		return &params.Bypass[3]
+	case 248:
+		return &params.Bypass[4]
+	case 249:
+		return &params.Bypass[5]
+	case 250:
+		return &params.Bypass[6]
+	case 251:
+		return &params.Bypass[7]
+	case 252:
+		return &params.Bypass[8]
+	case 253:
+		return &params.Bypass[9]
+	}
+	return nil
+}
@@ -0,0 +1,504 @@
+package exs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// Constants
+// ============================================================================
+
+// Payload sizes written by Encode for chunks without raw bytes. They are the
+// smallest sizes Logic writes that still hold the matching Exs* layout.
+const (
+	headerPayloadSize          = 80
+	zonePayloadSize            = 136
+	groupPayloadSize           = 132
+	samplePayloadSize          = 592
+	optionsPayloadSize         = 388
+	optionsExtendedPayloadSize = 1108
+)
+
+// Chunk header flags written by Encode, as found in files saved by Logic.
+const (
+	headerChunkFlags = uint32(0x40)
+	sampleChunkFlags = uint32(0x20020000)
+)
+
+// maxParams is the number of key/value slots of the options chunk.
+const maxParams = 100
+
+// ============================================================================
+// Encoder
+// ============================================================================
+
+// Encode writes exs as an .exs file to w, big endian if exs.BigEndian is set.
+//
+// Chunks that carry the Raw bytes they were read from, in the same byte
+// order, are written over those bytes, so fields the decoder does not know
+// about survive. Only the named fields of the Exs* layouts are replaced. The
+// instrument counts are taken from the Zones, Groups and Samples slices, and
+// the named fields of each zone, group and sample are written back over their
+// Exs* layouts first. Extra chunks are written last.
+func Encode(w io.Writer, exs *EXS) error {
+	e := &encoder{w: w, order: binary.ByteOrder(binary.LittleEndian), bigEndian: exs.BigEndian}
+	if exs.BigEndian {
+		e.order = binary.BigEndian
+	}
+
+	instrument := ExsInstrument{
+		NumZones:   uint32(len(exs.Zones)),
+		NumGroups:  uint32(len(exs.Groups)),
+		NumSamples: uint32(len(exs.Samples)),
+	}
+	header, fresh := e.base(exs.Raw, headerChunk, headerPayloadSize, chunkHeaderSize+binary.Size(instrument))
+	if fresh {
+		e.order.PutUint32(header[8:], 0xFFFFFFFF)
+		e.order.PutUint32(header[12:], headerChunkFlags)
+		putString(header[20:84], exs.Name, false)
+	}
+	if err := e.putFields(header[chunkHeaderSize:], &instrument); err != nil {
+		return err
+	}
+	if err := e.write(header); err != nil {
+		return err
+	}
+
+	for i, zone := range exs.Zones {
+		if err := e.writeZone(zone); err != nil {
+			return fmt.Errorf("exs: zone %d: %w", i, err)
+		}
+	}
+	for i, group := range exs.Groups {
+		if err := e.writeGroup(group); err != nil {
+			return fmt.Errorf("exs: group %d: %w", i, err)
+		}
+	}
+	for i, sample := range exs.Samples {
+		if err := e.writeSample(sample); err != nil {
+			return fmt.Errorf("exs: sample %d: %w", i, err)
+		}
+	}
+	if exs.Params != nil {
+		if err := e.writeParams(exs.Params); err != nil {
+			return fmt.Errorf("exs: options: %w", err)
+		}
+	}
+	for _, chunk := range exs.Extra {
+		data := chunk.Data
+		if !e.sameOrder(data) {
+			data = e.reorder(data)
+		}
+		if err := e.write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type encoder struct {
+	w         io.Writer
+	order     binary.ByteOrder
+	bigEndian bool
+}
+
+// chunk returns an empty chunk of the given type with its header filled in.
+func (e *encoder) chunk(chunkType, size uint32) []byte {
+	data := make([]byte, chunkHeaderSize+int(size))
+	e.order.PutUint32(data[0:], chunkType<<24|0x0101)
+	e.order.PutUint32(data[4:], size)
+	magic := "TBOS"
+	if e.bigEndian {
+		magic = "SOBT"
+	}
+	copy(data[16:20], magic)
+	return data
+}
+
+// sameOrder reports whether the raw chunk data was written in the byte order
+// being encoded.
+func (e *encoder) sameOrder(data []byte) bool {
+	if len(data) < chunkHeaderSize {
+		return false
+	}
+	switch string(data[16:20]) {
+	case "TBOS", "JBOS":
+		return !e.bigEndian
+	case "SOBT", "SOBJ":
+		return e.bigEndian
+	}
+	return false
+}
+
+// reorder returns a copy of a chunk read in the other byte order with its
+// header rewritten in the order being encoded. The payload is kept as is,
+// since its layout is unknown.
+func (e *encoder) reorder(data []byte) []byte {
+	data = append([]byte{}, data...)
+	if len(data) < chunkHeaderSize {
+		return data
+	}
+	for off := 0; off < 16; off += 4 {
+		for i, j := off, off+3; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+	for i, j := 16, 19; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return data
+}
+
+// base returns the bytes a chunk is encoded over: a copy of raw if it is in
+// the byte order being encoded and holds at least minSize bytes, or else an
+// empty chunk of the given type and payload size. fresh reports the latter.
+// Raw chunks shorter than their Exs* layout, as older versions wrote them,
+// keep their size unless putChunk needs to grow them.
+func (e *encoder) base(raw []byte, chunkType, size uint32, minSize int) (data []byte, fresh bool) {
+	if len(raw) >= minSize && e.sameOrder(raw) {
+		return append([]byte{}, raw...), false
+	}
+	return e.chunk(chunkType, size), true
+}
+
+// grow returns data extended to a payload of size bytes, with the chunk
+// header updated. Data already that long is returned as is.
+func (e *encoder) grow(data []byte, size uint32) []byte {
+	if len(data) >= chunkHeaderSize+int(size) {
+		return data
+	}
+	grown := make([]byte, chunkHeaderSize+int(size))
+	copy(grown, data)
+	e.order.PutUint32(grown[4:], size)
+	return grown
+}
+
+// putChunk is putFields for a whole chunk that may be shorter than v. The
+// chunk keeps its size if the fields past its end are zero, and is grown to
+// a payload of size bytes otherwise.
+func (e *encoder) putChunk(data []byte, v interface{}, size uint32) ([]byte, error) {
+	n := binary.Size(v)
+	if len(data) >= n {
+		return data, e.putFields(data, v)
+	}
+	padded := make([]byte, n)
+	copy(padded, data)
+	if err := e.putFields(padded, v); err != nil {
+		return nil, err
+	}
+	if isZero(padded[len(data):]) {
+		return padded[:len(data)], nil
+	}
+	grown := e.grow(data, size)
+	copy(grown[chunkHeaderSize:], padded[chunkHeaderSize:])
+	return grown, nil
+}
+
+// isZero reports whether b holds only zeros.
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// decode decodes data into v, reading a missing tail as zeros.
+func (e *encoder) decode(data []byte, v interface{}) error {
+	if n := binary.Size(v); len(data) < n {
+		padded := make([]byte, n)
+		copy(padded, data)
+		data = padded
+	}
+	return binary.Read(bytes.NewReader(data), e.order, v)
+}
+
+// putFields encodes the named fields of the struct v points to into dst,
+// leaving the bytes under blank (_) fields untouched.
+func (e *encoder) putFields(dst []byte, v interface{}) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, e.order, v); err != nil {
+		return err
+	}
+	if buf.Len() > len(dst) {
+		return fmt.Errorf("%T does not fit in %d bytes", v, len(dst))
+	}
+	encoded := buf.Bytes()
+	t := reflect.TypeOf(v).Elem()
+	offset := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		size := binary.Size(reflect.Zero(field.Type).Interface())
+		if field.Name != "_" {
+			copy(dst[offset:offset+size], encoded[offset:offset+size])
+		}
+		offset += size
+	}
+	return nil
+}
+
+func (e *encoder) write(data []byte) error {
+	_, err := e.w.Write(data)
+	return err
+}
+
+func (e *encoder) writeZone(zone *Zone) error {
+	exsZone := zone.ExsZone
+	setString64(&exsZone.Name, zone.Name)
+	exsZone.Opts = setBit(exsZone.Opts, 0x01, zone.OneShot)
+	exsZone.Opts = setBit(exsZone.Opts, 0x02, !zone.Pitch)
+	exsZone.Opts = setBit(exsZone.Opts, 0x04, zone.Reverse)
+	exsZone.Opts = setBit(exsZone.Opts, 0x08, zone.VelocityRangeOn)
+	exsZone.Opts = setBit(exsZone.Opts, 0x40, zone.HasOutput)
+	loopOpts := uint32(0)
+	if zone.LoopOn {
+		loopOpts |= 0x01
+	}
+	if zone.LoopEqualPower {
+		loopOpts |= 0x02
+	}
+	if zone.LoopEndRelease {
+		loopOpts |= 0x04
+	}
+	exsZone.LoopOpts = exsZone.LoopOpts&^0x07 | loopOpts
+	exsZone.PlayMode = zone.PlayMode
+
+	data, _ := e.base(zone.Raw, zoneChunk, zonePayloadSize, chunkHeaderSize+minZonePayloadSize)
+	data, err := e.putChunk(data, &exsZone, zonePayloadSize)
+	if err != nil {
+		return err
+	}
+	return e.write(data)
+}
+
+func (e *encoder) writeGroup(group *Group) error {
+	exsGroup := group.ExsGroup
+	setString64(&exsGroup.Name, group.Name)
+	exsGroup.Decay = setBit(exsGroup.Decay, 0x40, group.Decay)
+	group.Selector.put(&exsGroup)
+
+	data, _ := e.base(group.Raw, groupChunk, groupPayloadSize, chunkHeaderSize)
+	data, err := e.putChunk(data, &exsGroup, groupPayloadSize)
+	if err != nil {
+		return err
+	}
+	return e.write(data)
+}
+
+func (e *encoder) writeSample(sample *Sample) error {
+	exsSample := sample.ExsSample
+	setString64(&exsSample.Name, sample.Name)
+	setString256(&exsSample.FileName, sample.FileName)
+	setString256(&exsSample.Path, sample.Path)
+
+	data, fresh := e.base(sample.Raw, sampleChunk, samplePayloadSize, chunkHeaderSize+minSamplePayloadSize)
+	if fresh {
+		e.order.PutUint32(data[12:], sampleChunkFlags)
+	}
+	data, err := e.putChunk(data, &exsSample, samplePayloadSize)
+	if err != nil {
+		return err
+	}
+	return e.write(data)
+}
+
+func (e *encoder) writeParams(params *Params) error {
+	var exsParams ExsParams
+	var extended ExsParamsExtended
+	fixedSize := binary.Size(exsParams)
+	extendedSize := fixedSize + binary.Size(extended)
+
+	size := uint32(optionsPayloadSize)
+	if params.hasExtended() {
+		size = optionsExtendedPayloadSize
+	}
+	data, fresh := e.base(params.Raw, optionsChunk, size, chunkHeaderSize)
+	if fresh {
+		e.order.PutUint32(data[chunkHeaderSize:], maxParams)
+	}
+	if params.hasExtended() && (fresh || len(data) < extendedSize) {
+		data = e.grow(data, optionsExtendedPayloadSize)
+		e.order.PutUint32(data[fixedSize:], uint32(len(extended.Params)))
+	}
+
+	if err := e.decode(data, &exsParams); err != nil {
+		return err
+	}
+	if err := params.putFixed(&exsParams); err != nil {
+		return err
+	}
+	data, err := e.putChunk(data, &exsParams, optionsPayloadSize)
+	if err != nil {
+		return err
+	}
+
+	if len(data) >= extendedSize {
+		if err := e.decode(data[fixedSize:], &extended); err != nil {
+			return err
+		}
+		if err := params.putExtended(&extended); err != nil {
+			return err
+		}
+		if err := e.putFields(data[fixedSize:], &extended); err != nil {
+			return err
+		}
+	}
+	return e.write(data)
+}
+
+// ============================================================================
+// Params encoding
+// ============================================================================
+
+// hasExtended reports whether params has parameters for the extended table.
+func (params *Params) hasExtended() bool {
+	for _, p := range params.Unknown {
+		if p.Extended {
+			return true
+		}
+	}
+	return false
+}
+
+// unknown returns the value of the unknown parameter key.
+func (params *Params) unknown(key uint16, extended bool) (int16, bool) {
+	for _, p := range params.Unknown {
+		if p.Key == key && p.Extended == extended {
+			return p.Value, true
+		}
+	}
+	return 0, false
+}
+
+// putFixed stores params in the 100 fixed slots of exsParams. It writes the
+// keys listed in params.Keys, or every known key holding a non zero value
+// and the unknown keys if params.Keys is empty. Slots left over are cleared,
+// except for values stored under key 0, which are kept as read.
+func (params *Params) putFixed(exsParams *ExsParams) error {
+	keys := params.Keys
+	if len(keys) == 0 {
+		for key := 1; key <= 255; key++ {
+			switch field := params.field(uint8(key)).(type) {
+			case *int16:
+				if *field != 0 {
+					keys = append(keys, uint8(key))
+				}
+			case *bool:
+				if *field {
+					keys = append(keys, uint8(key))
+				}
+			}
+		}
+		for _, p := range params.Unknown {
+			if !p.Extended && p.Key <= 255 {
+				keys = append(keys, uint8(p.Key))
+			}
+		}
+	}
+	if len(keys) > maxParams {
+		return fmt.Errorf("%d parameters do not fit in %d slots", len(keys), maxParams)
+	}
+	for i := range exsParams.Keys {
+		if i >= len(keys) {
+			if exsParams.Keys[i] != 0 {
+				exsParams.Keys[i], exsParams.Values[i] = 0, 0
+			}
+			continue
+		}
+		key := keys[i]
+		exsParams.Keys[i] = key
+		switch field := params.field(key).(type) {
+		case *int16:
+			exsParams.Values[i] = *field
+		case *bool:
+			exsParams.Values[i] = 0
+			if *field {
+				exsParams.Values[i] = 1
+			}
+		default:
+			exsParams.Values[i], _ = params.unknown(uint16(key), false)
+		}
+	}
+	return nil
+}
+
+// putExtended stores the extended unknown parameters in the extended table,
+// clearing slots left over the same way as putFixed.
+func (params *Params) putExtended(extended *ExsParamsExtended) error {
+	var entries []ExsParam
+	for _, p := range params.Unknown {
+		if p.Extended {
+			entries = append(entries, ExsParam{Key: p.Key, Value: p.Value})
+		}
+	}
+	if len(entries) > len(extended.Params) {
+		return fmt.Errorf("%d extended parameters do not fit in %d slots", len(entries), len(extended.Params))
+	}
+	for i := range extended.Params {
+		switch {
+		case i < len(entries):
+			extended.Params[i] = entries[i]
+		case extended.Params[i].Key != 0:
+			extended.Params[i] = ExsParam{}
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// Helpers
+// ============================================================================
+
+// setBit sets or clears mask in v.
+func setBit(v, mask uint8, on bool) uint8 {
+	if on {
+		return v | mask
+	}
+	return v &^ mask
+}
+
+// setString64 stores s in b, unless b already decodes to s. Leaving matching
+// fields alone keeps whatever Logic stored after the terminating NUL, and the
+// original encoding. Fields read as MacRoman stay MacRoman if s fits.
+func setString64(b *[64]byte, s string) {
+	if getString64(*b) != s {
+		macRoman := isMacRoman(b[:])
+		*b = [64]byte{}
+		putString(b[:], s, macRoman)
+	}
+}
+
+// setString256 is setString64 for the 256 byte path fields.
+func setString256(b *[256]byte, s string) {
+	if getString256(*b) != s {
+		macRoman := isMacRoman(b[:])
+		*b = [256]byte{}
+		putString(b[:], s, macRoman)
+	}
+}
+
+// putString copies s into b as UTF-8, or as MacRoman if macRoman is set and
+// s fits, truncated on a character boundary so the field stays NUL
+// terminated.
+func putString(b []byte, s string, macRoman bool) {
+	if macRoman {
+		if encoded, ok := encodeMacRoman(s); ok {
+			if len(encoded) > len(b)-1 {
+				encoded = encoded[:len(b)-1]
+			}
+			copy(b, encoded)
+			return
+		}
+	}
+	for len(s) > len(b)-1 {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	copy(b, s)
+}
@@ -0,0 +1,177 @@
+package exs_test
+
+import (
+	"bytes"
+	"path/filepath"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encode", func() {
+	files, _ := filepath.Glob("testdata/*.exs")
+
+	// roundTrip encodes e and decodes the result again.
+	roundTrip := func(e *exs.EXS) *exs.EXS {
+		var buf bytes.Buffer
+		Expect(exs.Encode(&buf, e)).To(Succeed())
+		decoded, err := exs.NewFromReader(bytes.NewReader(buf.Bytes()), e.Name)
+		Expect(err).To(BeNil())
+		return decoded
+	}
+
+	// withoutRaw returns a copy of e without the raw chunk bytes, which
+	// differ as soon as a field or the byte order changes.
+	withoutRaw := func(e *exs.EXS) *exs.EXS {
+		c := *e
+		c.Raw = nil
+		c.Zones, c.Groups, c.Samples = nil, nil, nil
+		for _, zone := range e.Zones {
+			z := *zone
+			z.Raw = nil
+			c.Zones = append(c.Zones, &z)
+		}
+		for _, group := range e.Groups {
+			g := *group
+			g.Raw = nil
+			c.Groups = append(c.Groups, &g)
+		}
+		for _, sample := range e.Samples {
+			s := *sample
+			s.Raw = nil
+			c.Samples = append(c.Samples, &s)
+		}
+		if e.Params != nil {
+			p := *e.Params
+			p.Raw = nil
+			c.Params = &p
+		}
+		return &c
+	}
+
+	// expectSameModel compares everything Encode writes.
+	expectSameModel := func(got, want *exs.EXS) {
+		got, want = withoutRaw(got), withoutRaw(want)
+		Expect(got.Instrument).To(Equal(want.Instrument))
+		Expect(got.Zones).To(Equal(want.Zones))
+		Expect(got.Groups).To(Equal(want.Groups))
+		Expect(got.Samples).To(Equal(want.Samples))
+		Expect(got.Params).To(Equal(want.Params))
+		Expect(len(got.Extra)).To(Equal(len(want.Extra)))
+		for i := range got.Extra {
+			Expect(got.Extra[i].Type).To(Equal(want.Extra[i].Type))
+		}
+	}
+
+	It("should have test files", func() {
+		Expect(files).ToNot(BeEmpty())
+	})
+
+	for _, file := range files {
+		file := file
+		It("should round trip "+filepath.Base(file), func() {
+			original, err := exs.NewFromFile(file)
+			Expect(err).To(BeNil())
+
+			decoded := roundTrip(original)
+			Expect(decoded.BigEndian).To(BeFalse())
+			expectSameModel(decoded, original)
+
+			// encoding the decoded file again must give the same bytes
+			var first, second bytes.Buffer
+			Expect(exs.Encode(&first, original)).To(Succeed())
+			Expect(exs.Encode(&second, decoded)).To(Succeed())
+			Expect(second.Bytes()).To(Equal(first.Bytes()))
+		})
+
+		It("should round trip "+filepath.Base(file)+" as big endian", func() {
+			original, err := exs.NewFromFile(file)
+			Expect(err).To(BeNil())
+			original.BigEndian = true
+
+			decoded := roundTrip(original)
+			Expect(decoded.BigEndian).To(BeTrue())
+			expectSameModel(decoded, original)
+		})
+	}
+
+	It("should write the named fields over the binary layout", func() {
+		original, err := exs.NewFromFile("testdata/MC-202 bass.exs")
+		Expect(err).To(BeNil())
+		original.Zones[0].Name = "Renamed"
+		original.Zones[0].Reverse = !original.Zones[0].Reverse
+		original.Zones[0].LoopOn = !original.Zones[0].LoopOn
+		original.Samples[0].FileName = "renamed.wav"
+		original.Params.OutputVolume = -12
+
+		decoded := roundTrip(original)
+		Expect(decoded.Zones[0].Name).To(Equal("Renamed"))
+		Expect(decoded.Zones[0].Reverse).To(Equal(original.Zones[0].Reverse))
+		Expect(decoded.Zones[0].LoopOn).To(Equal(original.Zones[0].LoopOn))
+		Expect(decoded.Samples[0].FileName).To(Equal("renamed.wav"))
+		Expect(decoded.Params.OutputVolume).To(Equal(int16(-12)))
+	})
+
+	It("should keep the bytes of fields it does not know", func() {
+		original, err := exs.NewFromFile("testdata/MC-202 bass.exs")
+		Expect(err).To(BeNil())
+		Expect(original.Raw).ToNot(BeEmpty())
+		Expect(original.Zones[0].Raw).ToNot(BeEmpty())
+		Expect(original.Samples[0].Raw).ToNot(BeEmpty())
+		Expect(original.Params.Raw).ToNot(BeEmpty())
+
+		decoded := roundTrip(original)
+		Expect(decoded.Raw).To(Equal(original.Raw))
+		for i := range original.Zones {
+			Expect(decoded.Zones[i].Raw).To(Equal(original.Zones[i].Raw))
+		}
+		for i := range original.Groups {
+			Expect(decoded.Groups[i].Raw).To(Equal(original.Groups[i].Raw))
+		}
+		for i := range original.Samples {
+			Expect(decoded.Samples[i].Raw).To(Equal(original.Samples[i].Raw))
+		}
+		Expect(decoded.Params.Raw).To(Equal(original.Params.Raw))
+		Expect(decoded.Params.Unknown).To(Equal(original.Params.Unknown))
+		for i := range original.Extra {
+			Expect(decoded.Extra[i].Data).To(Equal(original.Extra[i].Data))
+		}
+	})
+
+	It("should keep unknown parameters", func() {
+		e := &exs.EXS{
+			Name: "Unknown",
+			Params: &exs.Params{
+				OutputVolume: -6,
+				Unknown: []exs.Param{
+					{Key: 99, Value: 42},
+					{Key: 300, Value: -7, Extended: true},
+				},
+			},
+		}
+		decoded := roundTrip(e)
+		Expect(decoded.Params.OutputVolume).To(Equal(int16(-6)))
+		Expect(decoded.Params.Unknown).To(Equal(e.Params.Unknown))
+	})
+
+	It("should encode an instrument built from scratch", func() {
+		e := &exs.EXS{
+			Name:    "Scratch",
+			Zones:   []*exs.Zone{{Name: "C3", Pitch: true}},
+			Groups:  []*exs.Group{{Name: "Group"}},
+			Samples: []*exs.Sample{{Name: "C3", FileName: "C3.wav"}},
+			Params:  &exs.Params{OutputVolume: -6, Voices: 16, FilterOn: true},
+		}
+		decoded := roundTrip(e)
+		Expect(decoded.Instrument.NumZones).To(Equal(uint32(1)))
+		Expect(decoded.Instrument.NumGroups).To(Equal(uint32(1)))
+		Expect(decoded.Instrument.NumSamples).To(Equal(uint32(1)))
+		Expect(decoded.Zones[0].Name).To(Equal("C3"))
+		Expect(decoded.Zones[0].Pitch).To(BeTrue())
+		Expect(decoded.Samples[0].FileName).To(Equal("C3.wav"))
+		Expect(decoded.Params.OutputVolume).To(Equal(int16(-6)))
+		Expect(decoded.Params.Voices).To(Equal(int16(16)))
+		Expect(decoded.Params.FilterOn).To(BeTrue())
+	})
+})
@@ -0,0 +1,58 @@
+package exs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ============================================================================
+// Errors
+// ============================================================================
+
+var (
+	// ErrTruncatedChunk is returned when a chunk extends past the end of the file.
+	ErrTruncatedChunk = errors.New("truncated chunk")
+	// ErrBadMagic is returned when the file header does not carry an EXS magic.
+	ErrBadMagic = errors.New("not an exs file")
+	// ErrUnknownChunkSize is returned when a chunk has a size no known layout uses.
+	ErrUnknownChunkSize = errors.New("unknown chunk size")
+)
+
+// ChunkError describes a decoding failure and where in the file it happened.
+// Use errors.Is against the Err* sentinels to find out what went wrong.
+type ChunkError struct {
+	Err    error  // one of the Err* sentinels, or an underlying I/O error
+	Offset int64  // byte offset of the chunk header
+	Index  int    // zero based position of the chunk in the file
+	Type   uint32 // chunk type, e.g. zoneChunk
+	Size   uint32 // payload size as stored in the chunk header
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("exs: chunk %d (%s, size %d) at offset %d: %v",
+		e.Index, chunkTypeName(e.Type), e.Size, e.Offset, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// chunkTypeName returns a human readable name for a chunk type.
+func chunkTypeName(chunkType uint32) string {
+	switch chunkType {
+	case headerChunk:
+		return "header"
+	case zoneChunk:
+		return "zone"
+	case groupChunk:
+		return "group"
+	case sampleChunk:
+		return "sample"
+	case optionsChunk:
+		return "options"
+	case plistChunk:
+		return "plist"
+	default:
+		return fmt.Sprintf("type %d", chunkType)
+	}
+}
@@ -1,6 +1,13 @@
 package exs_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/cldmnky/exsconvert/pkg/exs"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -160,6 +167,269 @@ var _ = Describe("Exs", func() {
 		}
 	})
 
+	It("should count zones, groups and samples in the instrument header", func() {
+		exsFile, err := exs.NewFromFile("testdata/K3 Big.exs")
+		Expect(err).To(BeNil())
+		Expect(exsFile.Instrument.NumZones).To(BeEquivalentTo(len(exsFile.Zones)))
+		Expect(exsFile.Instrument.NumGroups).To(BeEquivalentTo(len(exsFile.Groups)))
+		Expect(exsFile.Instrument.NumSamples).To(BeEquivalentTo(len(exsFile.Samples)))
+	})
+
+	Context("when decoding from an io.ReaderAt", func() {
+		var data []byte
+
+		BeforeEach(func() {
+			var err error
+			data, err = os.ReadFile("testdata/Hi Hat 909 Clean.exs")
+			Expect(err).To(BeNil())
+		})
+
+		It("should decode the same instrument as NewFromFile", func() {
+			fromFile, err := exs.NewFromFile("testdata/Hi Hat 909 Clean.exs")
+			Expect(err).To(BeNil())
+			fromReader, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "Hi Hat 909 Clean")
+			Expect(err).To(BeNil())
+			Expect(fromReader.Zones).To(Equal(fromFile.Zones))
+			Expect(fromReader.Samples).To(Equal(fromFile.Samples))
+		})
+
+		It("should report truncated chunks with their offset and index", func() {
+			// cut the file in the middle of the second zone chunk
+			truncated := data[:500]
+			_, err := exs.NewFromReaderAt(bytes.NewReader(truncated), int64(len(truncated)), "truncated")
+			Expect(errors.Is(err, exs.ErrTruncatedChunk)).To(BeTrue())
+			var chunkErr *exs.ChunkError
+			Expect(errors.As(err, &chunkErr)).To(BeTrue())
+			Expect(chunkErr.Index).To(Equal(2))
+			Expect(chunkErr.Offset).To(BeEquivalentTo(384))
+			Expect(err.Error()).To(ContainSubstring("offset 384"))
+
+		})
+
+		It("should ignore trailing bytes too short for a chunk header", func() {
+			// cut the file 16 bytes into the header of the second zone chunk
+			exsFile, err := exs.NewFromReaderAt(bytes.NewReader(data[:400]), 400, "trailing")
+			Expect(err).To(BeNil())
+			Expect(exsFile.Zones).To(HaveLen(1))
+
+			// padding after the last chunk
+			padded := append(append([]byte{}, data...), make([]byte, 40)...)
+			fromPadded, err := exs.NewFromReaderAt(bytes.NewReader(padded), int64(len(padded)), "padded")
+			Expect(err).To(BeNil())
+			whole, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "whole")
+			Expect(err).To(BeNil())
+			Expect(fromPadded.Zones).To(Equal(whole.Zones))
+			Expect(fromPadded.Samples).To(Equal(whole.Samples))
+		})
+
+		It("should not read past the given size", func() {
+			_, err := exs.NewFromReaderAt(bytes.NewReader(data), 100, "short")
+			Expect(errors.Is(err, exs.ErrTruncatedChunk)).To(BeTrue())
+		})
+
+		It("should reject files without an EXS magic", func() {
+			corrupt := append([]byte{}, data...)
+			copy(corrupt[16:20], "RIFF")
+			_, err := exs.NewFromReaderAt(bytes.NewReader(corrupt), int64(len(corrupt)), "corrupt")
+			Expect(errors.Is(err, exs.ErrBadMagic)).To(BeTrue())
+			var chunkErr *exs.ChunkError
+			Expect(errors.As(err, &chunkErr)).To(BeTrue())
+			Expect(chunkErr.Offset).To(BeZero())
+		})
+
+		It("should reject chunks with an unknown size", func() {
+			corrupt := append([]byte{}, data...)
+			// shrink the first zone chunk below the smallest known zone layout
+			binary.LittleEndian.PutUint32(corrupt[164+4:], 90)
+			_, err := exs.NewFromReaderAt(bytes.NewReader(corrupt), int64(len(corrupt)), "corrupt")
+			Expect(errors.Is(err, exs.ErrUnknownChunkSize)).To(BeTrue())
+			var chunkErr *exs.ChunkError
+			Expect(errors.As(err, &chunkErr)).To(BeTrue())
+			Expect(chunkErr.Index).To(Equal(1))
+			Expect(chunkErr.Offset).To(BeEquivalentTo(164))
+		})
+	})
+
+	Context("when the file is a big endian EXS24 mk1 file", func() {
+		// bigEndianChunk returns a chunk in the layout PowerPC versions of the
+		// EXS24 wrote, with the given type, payload size and name.
+		bigEndianChunk := func(chunkType uint32, size int, magic, name string) []byte {
+			data := make([]byte, 84+size)
+			binary.BigEndian.PutUint32(data[0:], chunkType<<24|0x0101)
+			binary.BigEndian.PutUint32(data[4:], uint32(size))
+			copy(data[16:20], magic)
+			copy(data[20:84], name)
+			return data
+		}
+
+		// mk1File synthesises an instrument with two zones in one group, two
+		// samples in the short mk1 sample layout and an options chunk. Names
+		// are MacRoman.
+		mk1File := func() []byte {
+			var file []byte
+			header := bigEndianChunk(0x00, 80, "SOBJ", "C\x8elesta")
+			binary.BigEndian.PutUint32(header[88:], 2) // zones
+			binary.BigEndian.PutUint32(header[92:], 1) // groups
+			binary.BigEndian.PutUint32(header[96:], 2) // samples
+			file = append(file, header...)
+			for i := 0; i < 2; i++ {
+				zone := bigEndianChunk(0x01, 96, "SOBT", fmt.Sprintf("C\x8elesta %d", i))
+				zone[84] = 0x01 // one shot
+				zone[85] = byte(60 + 12*i)
+				zone[90] = byte(48 + 12*i)
+				zone[91] = byte(59 + 12*i)
+				zone[94] = 127
+				binary.BigEndian.PutUint32(zone[100:], 44100)
+				binary.BigEndian.PutUint32(zone[172:], 0)
+				binary.BigEndian.PutUint32(zone[176:], uint32(i))
+				file = append(file, zone...)
+			}
+			group := bigEndianChunk(0x02, 104, "SOBT", "Mallets")
+			group[84] = 0xFD // -3 dB
+			group[86] = 8    // polyphony
+			file = append(file, group...)
+			for i := 0; i < 2; i++ {
+				sample := bigEndianChunk(0x03, 336, "SOBT", fmt.Sprintf("C\x8elesta C%d.aif", 3+i))
+				binary.BigEndian.PutUint32(sample[88:], 44100)
+				binary.BigEndian.PutUint32(sample[92:], 44100)
+				sample[96] = 16
+				copy(sample[164:], "Macintosh HD:Samples:C\x8elesta")
+				file = append(file, sample...)
+			}
+			options := bigEndianChunk(0x04, 388, "SOBT", "")
+			binary.BigEndian.PutUint32(options[84:], 100)
+			for i, kv := range [][2]int16{{7, -6}, {5, 16}, {82, 20}} {
+				options[88+i] = byte(kv[0])
+				binary.BigEndian.PutUint16(options[188+2*i:], uint16(kv[1]))
+			}
+			return append(file, options...)
+		}
+
+		It("should decode every chunk in big endian order", func() {
+			data := mk1File()
+			e, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "mk1")
+			Expect(err).To(BeNil())
+			Expect(e.BigEndian).To(BeTrue())
+			Expect(e.Instrument.NumZones).To(Equal(uint32(2)))
+			Expect(e.Instrument.NumGroups).To(Equal(uint32(1)))
+			Expect(e.Instrument.NumSamples).To(Equal(uint32(2)))
+
+			Expect(e.Zones).To(HaveLen(2))
+			zone := e.Zones[1]
+			Expect(zone.Name).To(Equal("Célesta 1"))
+			Expect(zone.OneShot).To(BeTrue())
+			Expect(zone.Key).To(Equal(uint8(72)))
+			Expect(zone.KeyLow).To(Equal(int8(60)))
+			Expect(zone.KeyHigh).To(Equal(int8(71)))
+			Expect(zone.SampleEnd).To(Equal(int32(44100)))
+			Expect(zone.GroupIndex).To(Equal(int32(0)))
+			Expect(zone.SampleIndex).To(Equal(int32(1)))
+
+			Expect(e.Groups).To(HaveLen(1))
+			Expect(e.Groups[0].Name).To(Equal("Mallets"))
+			Expect(e.Groups[0].Volume).To(Equal(int8(-3)))
+			Expect(e.Groups[0].Polyphony).To(Equal(int8(8)))
+
+			Expect(e.Samples).To(HaveLen(2))
+			sample := e.Samples[0]
+			Expect(sample.Name).To(Equal("Célesta C3.aif"))
+			Expect(sample.FileName).To(BeEmpty())
+			Expect(sample.Path).To(Equal("Macintosh HD:Samples:Célesta"))
+			Expect(sample.Length).To(Equal(int32(44100)))
+			Expect(sample.Rate).To(Equal(int32(44100)))
+			Expect(sample.BitDepth).To(Equal(uint8(16)))
+
+			Expect(e.Params).ToNot(BeNil())
+			Expect(e.Params.OutputVolume).To(Equal(int16(-6)))
+			Expect(e.Params.Voices).To(Equal(int16(16)))
+			Expect(e.Params.Env2Attack).To(Equal(int16(20)))
+			Expect(e.Validate()).To(BeEmpty())
+		})
+
+		It("should encode the file back byte for byte", func() {
+			data := mk1File()
+			e, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "mk1")
+			Expect(err).To(BeNil())
+			var buf bytes.Buffer
+			Expect(exs.Encode(&buf, e)).To(Succeed())
+			Expect(buf.Bytes()).To(Equal(data))
+		})
+
+		It("should grow short chunks only for fields that do not fit", func() {
+			data := mk1File()
+			e, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "mk1")
+			Expect(err).To(BeNil())
+			e.Samples[1].FileName = "Célesta C4.aif"
+			e.Zones[0].SampleStart = 10
+
+			var buf bytes.Buffer
+			Expect(exs.Encode(&buf, e)).To(Succeed())
+			decoded, err := exs.NewFromReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()), "mk1")
+			Expect(err).To(BeNil())
+			Expect(decoded.BigEndian).To(BeTrue())
+			Expect(decoded.Samples[1].FileName).To(Equal("Célesta C4.aif"))
+			Expect(decoded.Samples[1].Raw).To(HaveLen(84 + 592))
+			Expect(decoded.Samples[0].Raw).To(HaveLen(84 + 336))
+			Expect(decoded.Zones[0].SampleStart).To(Equal(int32(10)))
+			Expect(decoded.Zones[0].Raw).To(HaveLen(84 + 96))
+		})
+
+		It("should not be confused with a little endian file", func() {
+			data := mk1File()
+			// the same bytes with a little endian magic read as garbage sizes
+			copy(data[16:20], "TBOS")
+			_, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "mk1")
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Context("when the file has a plist chunk", func() {
+		// bplist00 written by Python's plistlib, the decoder keeps whatever
+		// dictionary the chunk holds
+		const plist = "62706c6973743030d801020304050607080911121318191a1b5d4172746963756c6174696f6e73534269675442" +
+			"6c6f625647726f757073544e616d655756657273696f6e545768656e555a6f6e6573a20a0ed20b050c0d5249441001564c656761" +
+			"746fd20b050f10100258537461636361746f1200011170420102a114d21505161755436f6c6f7210035353757367004300e90" +
+			"06c006500730074006113fffffffffffffff93341c1de0c40000000a21c21d21d1e1f2057456e61626c6564544761696e0923bff" +
+			"8000000000000d21d1e2223082300000000000000000819272b30373c44494f52575a5c63686a73787b7d82888a8e9da6afb2b7b" +
+			"fc4c5ced3d400000000000001010000000000000024000000000000000000000000000000dd"
+
+		// withChunk appends a chunk of the given type and payload to an exs file.
+		withChunk := func(data []byte, signature uint32, payload []byte) []byte {
+			header := make([]byte, 84)
+			binary.LittleEndian.PutUint32(header[0:], signature)
+			binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+			copy(header[16:], "TBOS")
+			out := append(append([]byte{}, data...), header...)
+			return append(out, payload...)
+		}
+
+		It("should decode the plist", func() {
+			data, err := os.ReadFile("testdata/MC-202 bass.exs")
+			Expect(err).To(BeNil())
+			payload, err := hex.DecodeString(plist)
+			Expect(err).To(BeNil())
+			data = withChunk(data, 0x0B000101, payload)
+
+			exsFile, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "MC-202 bass")
+			Expect(err).To(BeNil())
+			Expect(exsFile.Plist).To(HaveKeyWithValue("Name", "Célesta"))
+			zones, ok := exsFile.Plist.Array("Zones")
+			Expect(ok).To(BeTrue())
+			Expect(zones).To(HaveLen(2))
+		})
+
+		It("should still load the instrument when the plist is corrupt", func() {
+			data, err := os.ReadFile("testdata/MC-202 bass.exs")
+			Expect(err).To(BeNil())
+			data = withChunk(data, 0x0B000101, []byte("bplist00 garbage"))
+
+			exsFile, err := exs.NewFromReaderAt(bytes.NewReader(data), int64(len(data)), "MC-202 bass")
+			Expect(err).To(BeNil())
+			Expect(exsFile.Plist).To(BeNil())
+			Expect(exsFile.Zones).To(HaveLen(2))
+		})
+	})
+
 	/* 	It("should detect endianness", func() {
 	   		exs, err := exs.NewExsFromFile("testdata/MC-202 bass.exs")
 	   		Expect(err).To(BeNil())
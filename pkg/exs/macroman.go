@@ -0,0 +1,71 @@
+package exs
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// MacRoman
+// ============================================================================
+
+// macRoman maps the bytes 0x80 to 0xFF of the MacRoman encoding, used for
+// names and paths by instruments saved on classic Mac OS, to runes.
+var macRoman = [128]rune{
+	'Ä', 'Å', 'Ç', 'É', 'Ñ', 'Ö', 'Ü', 'á', 'à', 'â', 'ä', 'ã', 'å', 'ç', 'é', 'è',
+	'ê', 'ë', 'í', 'ì', 'î', 'ï', 'ñ', 'ó', 'ò', 'ô', 'ö', 'õ', 'ú', 'ù', 'û', 'ü',
+	'†', '°', '¢', '£', '§', '•', '¶', 'ß', '®', '©', '™', '´', '¨', '≠', 'Æ', 'Ø',
+	'∞', '±', '≤', '≥', '¥', 'µ', '∂', '∑', '∏', 'π', '∫', 'ª', 'º', 'Ω', 'æ', 'ø',
+	'¿', '¡', '¬', '√', 'ƒ', '≈', '∆', '«', '»', '…', ' ', 'À', 'Ã', 'Õ', 'Œ', 'œ',
+	'–', '—', '“', '”', '‘', '’', '÷', '◊', 'ÿ', 'Ÿ', '⁄', '€', '‹', '›', 'ﬁ', 'ﬂ',
+	'‡', '·', '‚', '„', '‰', 'Â', 'Ê', 'Á', 'Ë', 'È', 'Í', 'Î', 'Ï', 'Ì', 'Ó', 'Ô',
+	'', 'Ò', 'Ú', 'Û', 'Ù', 'ı', 'ˆ', '˜', '¯', '˘', '˙', '˚', '¸', '˝', '˛', 'ˇ',
+}
+
+// decodeMacRoman converts MacRoman bytes to a string.
+func decodeMacRoman(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c < 0x80 {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteRune(macRoman[c-0x80])
+		}
+	}
+	return sb.String()
+}
+
+// encodeMacRoman converts s to MacRoman bytes, or returns false if s holds
+// a rune MacRoman cannot store.
+func encodeMacRoman(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r < 0x80 {
+			b = append(b, byte(r))
+			continue
+		}
+		c := macRomanByte(r)
+		if c == 0 {
+			return nil, false
+		}
+		b = append(b, c)
+	}
+	return b, true
+}
+
+// macRomanByte returns the MacRoman byte of r, or 0 if there is none.
+func macRomanByte(r rune) byte {
+	for i, m := range macRoman {
+		if m == r {
+			return byte(0x80 + i)
+		}
+	}
+	return 0
+}
+
+// isMacRoman reports whether a NUL terminated string field is stored in
+// MacRoman rather than UTF-8. Plain ASCII is neither.
+func isMacRoman(b []byte) bool {
+	b = cString(b)
+	return !utf8.Valid(b)
+}
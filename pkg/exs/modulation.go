@@ -0,0 +1,270 @@
+package exs
+
+import "fmt"
+
+// ============================================================================
+// Modulation Matrix
+// ============================================================================
+
+// ModSlots is the number of routings in the modulation matrix.
+const ModSlots = 10
+
+// Keys of the modulation matrix. Slot n stores its Destination, Source and
+// Via under modSlotKey+n*modSlotKeys and the two keys after it.
+const (
+	modSlotKey  = 173
+	modSlotKeys = 6
+)
+
+// ModSource is a modulation source or via, as stored in Params.Source and
+// Params.Via. Negative values are the internal sources, 1 to 120 are MIDI
+// controllers.
+type ModSource int16
+
+// Modulation sources.
+const (
+	ModSourcePitchBend       ModSource = 0
+	ModSourceOff             ModSource = -1
+	ModSourceKey             ModSource = -2
+	ModSourceVelocity        ModSource = -3
+	ModSourceAftertouch      ModSource = -4
+	ModSourceReleaseVelocity ModSource = -5
+	ModSourceMax             ModSource = -6
+	ModSourceRandom          ModSource = -7
+	ModSourceSideChain       ModSource = -8
+	ModSourcePitchBendUp     ModSource = -9
+	ModSourcePitchBendDown   ModSource = -10
+	ModSourcePolyAftertouch  ModSource = -11
+	ModSourceLFO1            ModSource = -12
+	ModSourceLFO2            ModSource = -13
+	ModSourceEnv1            ModSource = -14
+	ModSourceEnv2            ModSource = -15
+	ModSourceLFO3            ModSource = -16
+	ModSourceKeyRandom       ModSource = -17
+
+	// MIDI controllers with a common name.
+	ModSourceModWheel   ModSource = 1
+	ModSourceBreath     ModSource = 2
+	ModSourceFoot       ModSource = 4
+	ModSourceVolume     ModSource = 7
+	ModSourcePan        ModSource = 10
+	ModSourceExpression ModSource = 11
+	ModSourceSustain    ModSource = 64
+)
+
+var modSourceNames = map[ModSource]string{
+	ModSourcePitchBend:       "Pitch Bend",
+	ModSourceOff:             "-off-",
+	ModSourceKey:             "Key",
+	ModSourceVelocity:        "Velocity",
+	ModSourceAftertouch:      "Aftertouch",
+	ModSourceReleaseVelocity: "Release Velocity",
+	ModSourceMax:             "Max",
+	ModSourceRandom:          "Note On Random",
+	ModSourceSideChain:       "Side Chain",
+	ModSourcePitchBendUp:     "Pitch Bend Up",
+	ModSourcePitchBendDown:   "Pitch Bend Down",
+	ModSourcePolyAftertouch:  "Poly Aftertouch",
+	ModSourceLFO1:            "LFO 1",
+	ModSourceLFO2:            "LFO 2",
+	ModSourceEnv1:            "Envelope 1",
+	ModSourceEnv2:            "Envelope 2",
+	ModSourceLFO3:            "LFO 3",
+	ModSourceKeyRandom:       "Key Random",
+}
+
+var controllerNames = map[ModSource]string{
+	ModSourceModWheel:   "Mod Wheel",
+	ModSourceBreath:     "Breath",
+	ModSourceFoot:       "Foot",
+	ModSourceVolume:     "Volume",
+	ModSourcePan:        "Pan",
+	ModSourceExpression: "Expression",
+	ModSourceSustain:    "Sustain",
+}
+
+// IsController reports whether s is a MIDI controller.
+func (s ModSource) IsController() bool {
+	return s >= 1 && s <= 120
+}
+
+func (s ModSource) String() string {
+	if name, ok := modSourceNames[s]; ok {
+		return name
+	}
+	if s.IsController() {
+		if name, ok := controllerNames[s]; ok {
+			return fmt.Sprintf("CC%d (%s)", s, name)
+		}
+		return fmt.Sprintf("CC%d", s)
+	}
+	return fmt.Sprintf("ModSource(%d)", int16(s))
+}
+
+// ModDestination is a modulation target, as stored in Params.Destination.
+type ModDestination int16
+
+// Modulation destinations.
+const (
+	ModDestOff             ModDestination = 0
+	ModDestSampleSelect    ModDestination = 1
+	ModDestVolume          ModDestination = 2
+	ModDestPan             ModDestination = 3
+	ModDestRelativeVolume  ModDestination = 4
+	ModDestSampleStart     ModDestination = 5
+	ModDestPitch           ModDestination = 6
+	ModDestGlideTime       ModDestination = 7
+	ModDestFilterCutoff    ModDestination = 8
+	ModDestFilterResonance ModDestination = 9
+	ModDestFilterDrive     ModDestination = 10
+	ModDestEnv1Attack      ModDestination = 11
+	ModDestEnv1Decay       ModDestination = 12
+	ModDestEnv1Release     ModDestination = 13
+	ModDestEnv1Time        ModDestination = 14
+	ModDestLFO1DecayDelay  ModDestination = 15
+	ModDestLFO1Speed       ModDestination = 16
+	ModDestLFO2Speed       ModDestination = 17
+	ModDestLFO3Speed       ModDestination = 18
+	ModDestEnv2Attack      ModDestination = 19
+	ModDestEnv2Decay       ModDestination = 20
+	ModDestEnv2Release     ModDestination = 21
+	ModDestEnv2Time        ModDestination = 22
+	ModDestEnv1Sustain     ModDestination = 23
+	ModDestEnv2Sustain     ModDestination = 24
+	ModDestHold            ModDestination = 25
+	ModDestLFO1Amount      ModDestination = 26
+	ModDestLFO2Amount      ModDestination = 27
+	ModDestLFO3Amount      ModDestination = 28
+	ModDestVelocityXFade   ModDestination = 29
+	ModDestDetune          ModDestination = 30
+)
+
+var modDestinationNames = map[ModDestination]string{
+	ModDestOff:             "-off-",
+	ModDestSampleSelect:    "Sample Select",
+	ModDestVolume:          "Volume",
+	ModDestPan:             "Pan",
+	ModDestRelativeVolume:  "Relative Volume",
+	ModDestSampleStart:     "Sample Start",
+	ModDestPitch:           "Pitch",
+	ModDestGlideTime:       "Glide Time",
+	ModDestFilterCutoff:    "Filter Cutoff",
+	ModDestFilterResonance: "Filter Resonance",
+	ModDestFilterDrive:     "Filter Drive",
+	ModDestEnv1Attack:      "Env 1 Attack",
+	ModDestEnv1Decay:       "Env 1 Decay",
+	ModDestEnv1Release:     "Env 1 Release",
+	ModDestEnv1Time:        "Env 1 Time",
+	ModDestLFO1DecayDelay:  "LFO 1 Decay/Delay",
+	ModDestLFO1Speed:       "LFO 1 Speed",
+	ModDestLFO2Speed:       "LFO 2 Speed",
+	ModDestLFO3Speed:       "LFO 3 Speed",
+	ModDestEnv2Attack:      "Env 2 Attack",
+	ModDestEnv2Decay:       "Env 2 Decay",
+	ModDestEnv2Release:     "Env 2 Release",
+	ModDestEnv2Time:        "Env 2 Time",
+	ModDestEnv1Sustain:     "Env 1 Sustain",
+	ModDestEnv2Sustain:     "Env 2 Sustain",
+	ModDestHold:            "Hold",
+	ModDestLFO1Amount:      "LFO 1 Amount",
+	ModDestLFO2Amount:      "LFO 2 Amount",
+	ModDestLFO3Amount:      "LFO 3 Amount",
+	ModDestVelocityXFade:   "Velocity XFade",
+	ModDestDetune:          "Detune",
+}
+
+func (d ModDestination) String() string {
+	if name, ok := modDestinationNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("ModDestination(%d)", int16(d))
+}
+
+// ModRouting is one slot of the modulation matrix.
+type ModRouting struct {
+	Slot        int // zero based slot in the matrix
+	Source      ModSource
+	Destination ModDestination
+	Via         ModSource // ModSourceOff if the routing has no via
+	Amount      float64   // [-1,1] amount, or amount at the minimum via value
+	AmountVia   float64   // [-1,1] amount at the maximum via value, equal to Amount without via
+	Invert      bool      // source is inverted
+	InvertVia   bool      // via is inverted
+}
+
+// HasVia reports whether the routing is scaled by a via source.
+func (r ModRouting) HasVia() bool {
+	return r.Via != ModSourceOff
+}
+
+func (r ModRouting) String() string {
+	if r.HasVia() {
+		return fmt.Sprintf("%s -> %s via %s (%+.3f..%+.3f)", r.Source, r.Destination, r.Via, r.Amount, r.AmountVia)
+	}
+	return fmt.Sprintf("%s -> %s (%+.3f)", r.Source, r.Destination, r.Amount)
+}
+
+// ModRouting returns the routing stored in slot, active or not. A
+// destination, source or via the instrument does not store is off, though
+// their zero values are the valid Pitch Bend source.
+func (params *Params) ModRouting(slot int) ModRouting {
+	r := ModRouting{
+		Slot:        slot,
+		Source:      ModSource(params.Source[slot]),
+		Destination: ModDestination(params.Destination[slot]),
+		Via:         ModSource(params.Via[slot]),
+		Amount:      scaleModAmount(params.Amount[slot]),
+		Invert:      params.Invert[slot],
+		InvertVia:   params.InvertVia[slot],
+	}
+	key := uint8(modSlotKey + slot*modSlotKeys)
+	if !params.stored(key) {
+		r.Destination = ModDestOff
+	}
+	if !params.stored(key + 1) {
+		r.Source = ModSourceOff
+	}
+	if !params.stored(key + 2) {
+		r.Via = ModSourceOff
+	}
+	r.AmountVia = r.Amount
+	if r.HasVia() {
+		r.AmountVia = scaleModAmount(params.AmountVia[slot])
+	}
+	return r
+}
+
+// ModRoutings returns the routings that affect the sound: slots that are not
+// bypassed, have a source and a destination and a non zero amount.
+func (params *Params) ModRoutings() []ModRouting {
+	var routings []ModRouting
+	for slot := 0; slot < ModSlots; slot++ {
+		if params.Bypass[slot] {
+			continue
+		}
+		r := params.ModRouting(slot)
+		if r.Source == ModSourceOff || r.Destination == ModDestOff {
+			continue
+		}
+		if r.Amount == 0 && r.AmountVia == 0 {
+			continue
+		}
+		routings = append(routings, r)
+	}
+	return routings
+}
+
+// scaleModAmount converts a stored amount of [-1000,1000] to [-1,1].
+func scaleModAmount(amount int16) float64 {
+	return clampUnit(float64(amount) / 1000)
+}
+
+func clampUnit(v float64) float64 {
+	if v < -1 {
+		return -1
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
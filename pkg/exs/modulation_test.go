@@ -0,0 +1,90 @@
+package exs_test
+
+import (
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Modulation", func() {
+	It("should name sources and destinations", func() {
+		Expect(exs.ModSourceVelocity.String()).To(Equal("Velocity"))
+		Expect(exs.ModSourceLFO1.String()).To(Equal("LFO 1"))
+		Expect(exs.ModSourceModWheel.String()).To(Equal("CC1 (Mod Wheel)"))
+		Expect(exs.ModSource(74).String()).To(Equal("CC74"))
+		Expect(exs.ModSource(-40).String()).To(Equal("ModSource(-40)"))
+		Expect(exs.ModDestFilterCutoff.String()).To(Equal("Filter Cutoff"))
+		Expect(exs.ModDestination(99).String()).To(Equal("ModDestination(99)"))
+	})
+
+	It("should return the active routings with scaled amounts", func() {
+		exsFile, err := exs.NewFromFile("testdata/MC-202 bass.exs")
+		Expect(err).To(BeNil())
+
+		routings := exsFile.Params.ModRoutings()
+		Expect(routings).To(HaveLen(2))
+		Expect(routings[0].Slot).To(Equal(0))
+		Expect(routings[0].Source).To(Equal(exs.ModSourceVelocity))
+		Expect(routings[0].Destination).To(Equal(exs.ModDestVolume))
+		Expect(routings[0].HasVia()).To(BeFalse())
+		Expect(routings[0].Amount).To(Equal(1.0))
+		Expect(routings[0].AmountVia).To(Equal(1.0))
+		Expect(routings[1].Source).To(Equal(exs.ModSourceModWheel))
+	})
+
+	It("should scale via amounts", func() {
+		exsFile, err := exs.NewFromFile("testdata/Strings - 360 From Mars.exs")
+		Expect(err).To(BeNil())
+
+		r := exsFile.Params.ModRouting(1)
+		Expect(r.Source).To(Equal(exs.ModSourceLFO1))
+		Expect(r.Via).To(Equal(exs.ModSourceModWheel))
+		Expect(r.Amount).To(Equal(0.0))
+		Expect(r.AmountVia).To(BeNumerically("~", 0.6, 1e-9))
+		Expect(exsFile.Params.ModRoutings()).To(ContainElement(r))
+	})
+
+	It("should turn off a via the instrument does not store", func() {
+		// Slots 4 and 5 of Analog Strings store no via key, which would read
+		// as Pitch Bend
+		exsFile, err := exs.NewFromFile("testdata/Analog Strings - Kawaii Dreams From Mars.exs")
+		Expect(err).To(BeNil())
+		for _, r := range exsFile.Params.ModRoutings() {
+			Expect(r.Source).ToNot(Equal(exs.ModSourcePitchBend), r.String())
+			Expect(r.Via).ToNot(Equal(exs.ModSourcePitchBend), r.String())
+		}
+		r := exsFile.Params.ModRouting(4)
+		Expect(r.Source).To(Equal(exs.ModSourceAftertouch))
+		Expect(r.Destination).To(Equal(exs.ModDestLFO1Speed))
+		Expect(r.HasVia()).To(BeFalse())
+
+		// A stored zero is Pitch Bend
+		params := &exs.Params{Keys: []uint8{173, 174, 175, 176}, Destination: [10]int16{int16(exs.ModDestPitch)}, Amount: [10]int16{500}}
+		r = params.ModRouting(0)
+		Expect(r.Source).To(Equal(exs.ModSourcePitchBend))
+		Expect(r.Via).To(Equal(exs.ModSourcePitchBend))
+		params.Keys = []uint8{173, 174, 176}
+		Expect(params.ModRouting(0).HasVia()).To(BeFalse())
+	})
+
+	It("should skip bypassed, empty and zero amount slots", func() {
+		params := &exs.Params{}
+		for slot := 0; slot < exs.ModSlots; slot++ {
+			params.Source[slot] = int16(exs.ModSourceOff)
+			params.Via[slot] = int16(exs.ModSourceOff)
+		}
+		params.Source[0] = int16(exs.ModSourceVelocity)
+		params.Destination[0] = int16(exs.ModDestPitch)
+		params.Amount[0] = -500
+		params.Source[1] = int16(exs.ModSourceLFO2)
+		params.Destination[1] = int16(exs.ModDestPan)
+		params.Amount[1] = 1000
+		params.Bypass[1] = true
+		params.Source[2] = int16(exs.ModSourceEnv1)
+		params.Destination[2] = int16(exs.ModDestFilterCutoff)
+
+		routings := params.ModRoutings()
+		Expect(routings).To(HaveLen(1))
+		Expect(routings[0].Amount).To(Equal(-0.5))
+	})
+})
@@ -0,0 +1,327 @@
+package exs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+// ============================================================================
+// Binary Property List (bplist00)
+// ============================================================================
+
+// ErrBadPlist is returned when the binary plist chunk cannot be decoded.
+var ErrBadPlist = errors.New("invalid binary plist")
+
+// Plist is a decoded plist dictionary. Values are one of Plist, []interface{},
+// string, int64, float64, bool, []byte, time.Time or PlistUID.
+type Plist map[string]interface{}
+
+// PlistUID is a keyed archiver object reference.
+type PlistUID uint64
+
+// String returns the string stored under key.
+func (p Plist) String(key string) (string, bool) {
+	v, ok := p[key].(string)
+	return v, ok
+}
+
+// Int returns the integer stored under key.
+func (p Plist) Int(key string) (int64, bool) {
+	v, ok := p[key].(int64)
+	return v, ok
+}
+
+// Dict returns the dictionary stored under key.
+func (p Plist) Dict(key string) (Plist, bool) {
+	v, ok := p[key].(Plist)
+	return v, ok
+}
+
+// Array returns the array stored under key.
+func (p Plist) Array(key string) ([]interface{}, bool) {
+	v, ok := p[key].([]interface{})
+	return v, ok
+}
+
+// plistEpoch is the reference date of plist dates.
+var plistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// plistMaxDepth limits nesting so a corrupt file cannot exhaust the stack.
+const plistMaxDepth = 64
+
+// plistMaxObjects limits how many objects are decoded, since shared
+// references can make a small file expand into a huge tree.
+const plistMaxObjects = 1 << 20
+
+type plistDecoder struct {
+	data          []byte
+	offsets       []uint64
+	objectRefSize int
+	decoded       int
+}
+
+// decodePlist decodes a bplist00 document.
+func decodePlist(data []byte) (interface{}, error) {
+	if len(data) < 8+32 || !bytes.HasPrefix(data, []byte("bplist00")) {
+		return nil, ErrBadPlist
+	}
+	trailer := data[len(data)-32:]
+	offsetIntSize := int(trailer[6])
+	objectRefSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	topObject := binary.BigEndian.Uint64(trailer[16:])
+	offsetTable := binary.BigEndian.Uint64(trailer[24:])
+	if offsetIntSize < 1 || offsetIntSize > 8 || objectRefSize < 1 || objectRefSize > 8 {
+		return nil, fmt.Errorf("%w: bad trailer", ErrBadPlist)
+	}
+	tableEnd := uint64(len(data) - 32)
+	if numObjects == 0 || topObject >= numObjects || offsetTable > tableEnd ||
+		numObjects > (tableEnd-offsetTable)/uint64(offsetIntSize) {
+		return nil, fmt.Errorf("%w: bad offset table", ErrBadPlist)
+	}
+	d := &plistDecoder{
+		data:          data,
+		offsets:       make([]uint64, numObjects),
+		objectRefSize: objectRefSize,
+	}
+	for i := range d.offsets {
+		start := offsetTable + uint64(i*offsetIntSize)
+		d.offsets[i] = readUint(data[start : start+uint64(offsetIntSize)])
+	}
+	return d.object(topObject, 0)
+}
+
+// readUint reads a big endian unsigned integer of len(b) bytes.
+func readUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// bytes returns n bytes at off, or an error if they are out of range.
+func (d *plistDecoder) bytes(off, n uint64) ([]byte, error) {
+	if off > uint64(len(d.data)) || n > uint64(len(d.data))-off {
+		return nil, fmt.Errorf("%w: object out of range", ErrBadPlist)
+	}
+	return d.data[off : off+n], nil
+}
+
+// count reads the length of a variable sized object. Lengths of 15 and above
+// are stored as an integer object following the marker.
+func (d *plistDecoder) count(marker byte, off uint64) (uint64, uint64, error) {
+	n := uint64(marker & 0x0F)
+	if n != 0x0F {
+		return n, off + 1, nil
+	}
+	b, err := d.bytes(off+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b[0]&0xF0 != 0x10 {
+		return 0, 0, fmt.Errorf("%w: bad length", ErrBadPlist)
+	}
+	size := uint64(1) << (b[0] & 0x0F)
+	v, err := d.bytes(off+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return readUint(v), off + 2 + size, nil
+}
+
+// refs reads n object references starting at off.
+func (d *plistDecoder) refs(off, n uint64) ([]uint64, error) {
+	if n > uint64(len(d.data))/uint64(d.objectRefSize) {
+		return nil, fmt.Errorf("%w: object out of range", ErrBadPlist)
+	}
+	b, err := d.bytes(off, n*uint64(d.objectRefSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		refs[i] = readUint(b[i*d.objectRefSize : (i+1)*d.objectRefSize])
+	}
+	return refs, nil
+}
+
+func (d *plistDecoder) object(ref uint64, depth int) (interface{}, error) {
+	if ref >= uint64(len(d.offsets)) {
+		return nil, fmt.Errorf("%w: bad object reference %d", ErrBadPlist, ref)
+	}
+	if depth > plistMaxDepth {
+		return nil, fmt.Errorf("%w: nested too deep", ErrBadPlist)
+	}
+	d.decoded++
+	if d.decoded > plistMaxObjects {
+		return nil, fmt.Errorf("%w: too many objects", ErrBadPlist)
+	}
+	off := d.offsets[ref]
+	m, err := d.bytes(off, 1)
+	if err != nil {
+		return nil, err
+	}
+	marker := m[0]
+	switch marker & 0xF0 {
+	case 0x00:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		case 0x00, 0x0F:
+			return nil, nil
+		}
+	case 0x10:
+		size := uint64(1) << (marker & 0x0F)
+		b, err := d.bytes(off+1, size)
+		if err != nil {
+			return nil, err
+		}
+		if size > 8 {
+			// 128 bit integers only carry meaningful data in the low 64 bits
+			b = b[size-8:]
+		}
+		// integers shorter than 8 bytes are unsigned, 8 byte integers signed
+		return int64(readUint(b)), nil
+	case 0x20:
+		size := uint64(1) << (marker & 0x0F)
+		b, err := d.bytes(off+1, size)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case 4:
+			return float64(math.Float32frombits(uint32(readUint(b)))), nil
+		case 8:
+			return math.Float64frombits(readUint(b)), nil
+		}
+	case 0x30:
+		if marker == 0x33 {
+			b, err := d.bytes(off+1, 8)
+			if err != nil {
+				return nil, err
+			}
+			seconds := math.Float64frombits(readUint(b))
+			return plistEpoch.Add(time.Duration(seconds * float64(time.Second))), nil
+		}
+	case 0x40:
+		n, start, err := d.count(marker, off)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(start, n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	case 0x50:
+		n, start, err := d.count(marker, off)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.bytes(start, n)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 0x60:
+		n, start, err := d.count(marker, off)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.data))/2 {
+			return nil, fmt.Errorf("%w: object out of range", ErrBadPlist)
+		}
+		b, err := d.bytes(start, n*2)
+		if err != nil {
+			return nil, err
+		}
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(b[i*2:])
+		}
+		return string(utf16.Decode(units)), nil
+	case 0x80:
+		b, err := d.bytes(off+1, uint64(marker&0x0F)+1)
+		if err != nil {
+			return nil, err
+		}
+		return PlistUID(readUint(b)), nil
+	case 0xA0, 0xC0:
+		n, start, err := d.count(marker, off)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(start, n)
+		if err != nil {
+			return nil, err
+		}
+		array := make([]interface{}, len(refs))
+		for i, r := range refs {
+			array[i], err = d.object(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	case 0xD0:
+		n, start, err := d.count(marker, off)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(start, n*2)
+		if err != nil {
+			return nil, err
+		}
+		dict := make(Plist, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := d.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: dictionary key is not a string", ErrBadPlist)
+			}
+			dict[key], err = d.object(refs[n+i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("%w: unknown object marker 0x%02x", ErrBadPlist, marker)
+}
+
+// ============================================================================
+// EXS Methods - Plist
+// ============================================================================
+
+// readPlist decodes the plist chunk. Its keys are not documented, the
+// dictionary is kept as it is on EXS.Plist. Mapping them onto EXS, Group
+// and Zone waits for an instrument saved by Sampler: none of the test files
+// has the chunk.
+func (exs *EXS) readPlist(data []byte) error {
+	payload := data[chunkHeaderSize:]
+	start := bytes.Index(payload, []byte("bplist00"))
+	if start < 0 {
+		return ErrBadPlist
+	}
+	root, err := decodePlist(payload[start:])
+	if err != nil {
+		return err
+	}
+	plist, ok := root.(Plist)
+	if !ok {
+		return fmt.Errorf("%w: root object is not a dictionary", ErrBadPlist)
+	}
+	exs.Plist = plist
+	return nil
+}
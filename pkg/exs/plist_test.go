@@ -0,0 +1,94 @@
+package exs
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testPlist is a bplist00 document written by Python's plistlib with the keys
+// Articulations, Groups, Zones, Blob, Version, Big, When and Name.
+const testPlist = "62706c6973743030d801020304050607080911121318191a1b5d4172746963756c6174696f6e73534269675442" +
+	"6c6f625647726f757073544e616d655756657273696f6e545768656e555a6f6e6573a20a0ed20b050c0d5249441001564c656761" +
+	"746fd20b050f10100258537461636361746f1200011170420102a114d21505161755436f6c6f7210035353757367004300e90" +
+	"06c006500730074006113fffffffffffffff93341c1de0c40000000a21c21d21d1e1f2057456e61626c6564544761696e0923bff" +
+	"8000000000000d21d1e2223082300000000000000000819272b30373c44494f52575a5c63686a73787b7d82888a8e9da6afb2b7b" +
+	"fc4c5ced3d400000000000001010000000000000024000000000000000000000000000000dd"
+
+func TestDecodePlist(t *testing.T) {
+	data, err := hex.DecodeString(testPlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := decodePlist(data)
+	if err != nil {
+		t.Fatalf("decodePlist() error = %v", err)
+	}
+	plist, ok := root.(Plist)
+	if !ok {
+		t.Fatalf("decodePlist() root = %T, want Plist", root)
+	}
+
+	if v, _ := plist.String("Name"); v != "Célesta" {
+		t.Errorf("Name = %q, want %q", v, "Célesta")
+	}
+	if v, _ := plist.Int("Version"); v != -7 {
+		t.Errorf("Version = %d, want -7", v)
+	}
+	if v, _ := plist.Int("Big"); v != 70000 {
+		t.Errorf("Big = %d, want 70000", v)
+	}
+	if v, _ := plist["Blob"].([]byte); string(v) != "\x01\x02" {
+		t.Errorf("Blob = %v, want [1 2]", v)
+	}
+	if v, _ := plist["When"].(time.Time); !v.Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("When = %v, want 2020-01-01", v)
+	}
+	zones, _ := plist.Array("Zones")
+	if len(zones) != 2 {
+		t.Fatalf("Zones has %d entries, want 2", len(zones))
+	}
+	zone := zones[0].(Plist)
+	if zone["Gain"] != -1.5 || zone["Enabled"] != true {
+		t.Errorf("Zones[0] = %v, want Gain -1.5 and Enabled true", zone)
+	}
+}
+
+func TestDecodePlistErrors(t *testing.T) {
+	valid, _ := hex.DecodeString(testPlist)
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{
+			name:  "empty",
+			input: nil,
+		},
+		{
+			name:  "wrong magic",
+			input: append([]byte("bplist01"), valid[8:]...),
+		},
+		{
+			name:  "truncated",
+			input: valid[:len(valid)-40],
+		},
+		{
+			name: "offset table out of range",
+			input: func() []byte {
+				b := append([]byte{}, valid...)
+				b[len(b)-1] = 0xFF
+				return b
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodePlist(tt.input)
+			if !errors.Is(err, ErrBadPlist) {
+				t.Errorf("decodePlist() error = %v, want ErrBadPlist", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package exs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog"
+)
+
+// ============================================================================
+// Sample Path Resolution
+// ============================================================================
+
+// ErrSampleNotFound is returned by SampleResolver.Resolve when no local file
+// matches a sample.
+var ErrSampleNotFound = errors.New("sample not found")
+
+// PathRule maps a directory prefix as stored in EXS files to a local
+// directory, e.g. /Users/bob/Music/Audio Music Apps/Samples to
+// /mnt/library/Samples.
+type PathRule struct {
+	From string
+	To   string
+}
+
+// ParsePathRule parses a rule written as from=to.
+func ParsePathRule(s string) (PathRule, error) {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok || from == "" || to == "" {
+		return PathRule{}, fmt.Errorf("invalid path rule %q, want from=to", s)
+	}
+	return PathRule{From: strings.TrimRight(from, "/"), To: to}, nil
+}
+
+// apply returns dir with the rule prefix replaced, or false if the rule does
+// not match.
+func (rule PathRule) apply(dir string) (string, bool) {
+	if dir != rule.From && !strings.HasPrefix(dir, rule.From+"/") {
+		return "", false
+	}
+	return filepath.Join(rule.To, filepath.FromSlash(strings.TrimPrefix(dir, rule.From))), true
+}
+
+// SampleResolver finds the local audio file of a Sample. It tries the
+// directory stored in Sample.Path, rewritten by Rules, as is and relative to
+// the .exs file, before falling back to files found by file name below the
+// search paths.
+type SampleResolver struct {
+	Rules []PathRule
+	index map[string][]string // file name -> paths, in walk order
+}
+
+// NewSampleResolver returns a resolver applying rules that indexes the files
+// below searchPaths.
+func NewSampleResolver(rules []PathRule, searchPaths ...string) (*SampleResolver, error) {
+	r := &SampleResolver{Rules: rules, index: make(map[string][]string)}
+	for _, root := range searchPaths {
+		if err := r.AddSearchPath(root); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// AddSearchPath indexes the files below root by file name.
+func (r *SampleResolver) AddSearchPath(root string) error {
+	count := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if len(r.index[name]) > 0 {
+			klog.V(3).Infof("duplicate sample file %s (%s, %s)", name, r.index[name][0], path)
+		}
+		r.index[name] = append(r.index[name], path)
+		count++
+		return nil
+	})
+	klog.V(2).Infof("Indexed %d sample files below %s", count, root)
+	return err
+}
+
+// Resolve returns the local path of the audio file of sample. exsPath is the
+// path of the .exs file the sample was read from, or empty if unknown. Of
+// several indexed files with the sample's name, the one whose directories
+// match the end of Sample.Path best wins.
+func (r *SampleResolver) Resolve(sample *Sample, exsPath string) (string, error) {
+	name := strings.TrimSpace(sample.FileName)
+	if name == "" {
+		name = strings.TrimSpace(sample.Name)
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: sample has no file name", ErrSampleNotFound)
+	}
+
+	for _, dir := range storedDirs(sample.Path) {
+		for _, candidate := range r.candidates(dir, exsPath) {
+			path := filepath.Join(candidate, name)
+			if isFile(path) {
+				return path, nil
+			}
+		}
+	}
+
+	paths := r.index[name]
+	if len(paths) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrSampleNotFound, name)
+	}
+	best, bestScore := paths[0], -1
+	for _, path := range paths {
+		score := 0
+		for _, dir := range storedDirs(sample.Path) {
+			if s := commonTail(filepath.Dir(path), dir); s > score {
+				score = s
+			}
+		}
+		if score > bestScore {
+			best, bestScore = path, score
+		}
+	}
+	return best, nil
+}
+
+// candidates returns the local directories that may hold the samples of
+// dir, a slash separated directory as stored in an EXS file.
+func (r *SampleResolver) candidates(dir, exsPath string) []string {
+	var dirs []string
+	for _, rule := range r.Rules {
+		if local, ok := rule.apply(dir); ok {
+			dirs = append(dirs, local)
+		}
+	}
+	local := filepath.FromSlash(dir)
+	if exsPath == "" {
+		return append(dirs, local)
+	}
+	exsDir := filepath.Dir(exsPath)
+	if !strings.HasPrefix(dir, "/") {
+		return append(dirs, filepath.Join(exsDir, local))
+	}
+	dirs = append(dirs, local)
+	// the library may have moved, so try ever longer tails of dir next to
+	// the .exs file
+	parts := splitDir(dir)
+	for i := len(parts); i >= 0; i-- {
+		dirs = append(dirs, filepath.Join(append([]string{exsDir}, parts[i:]...)...))
+	}
+	return dirs
+}
+
+// storedDirs returns the slash separated directories a Sample.Path may
+// stand for. HFS paths of older files, such as Macintosh HD:Users:bob, give
+// both /Users/bob and /Volumes/Macintosh HD/Users/bob.
+func storedDirs(path string) []string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+	if strings.Contains(path, "/") || !strings.Contains(path, ":") {
+		return []string{strings.TrimRight(path, "/")}
+	}
+	parts := strings.Split(strings.Trim(path, ":"), ":")
+	return []string{
+		"/" + strings.Join(parts[1:], "/"),
+		"/Volumes/" + strings.Join(parts, "/"),
+	}
+}
+
+// commonTail returns the number of trailing directory names local and
+// stored share.
+func commonTail(local, stored string) int {
+	a, b := splitDir(filepath.ToSlash(local)), splitDir(stored)
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// splitDir splits a slash separated directory into its names.
+func splitDir(dir string) []string {
+	var parts []string
+	for _, p := range strings.Split(dir, "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
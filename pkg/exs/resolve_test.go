@@ -0,0 +1,102 @@
+package exs_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SampleResolver", func() {
+	var root string
+
+	// touch creates an empty file below root and returns its path.
+	touch := func(parts ...string) string {
+		path := filepath.Join(append([]string{root}, parts...)...)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, nil, 0644)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		var err error
+		root, err = os.MkdirTemp("", "exs_resolve")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	It("should parse path rules", func() {
+		rule, err := exs.ParsePathRule("/Users/bob/Music/Samples/=/mnt/library/Samples")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rule).To(Equal(exs.PathRule{From: "/Users/bob/Music/Samples", To: "/mnt/library/Samples"}))
+		_, err = exs.ParsePathRule("/Users/bob")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should use the stored absolute path", func() {
+		want := touch("Strings", "C3.wav")
+		r, err := exs.NewSampleResolver(nil)
+		Expect(err).ToNot(HaveOccurred())
+		got, err := r.Resolve(&exs.Sample{FileName: "C3.wav", Path: filepath.Join(root, "Strings")}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(want))
+	})
+
+	It("should remap path prefixes", func() {
+		want := touch("library", "Samples", "Piano", "C3.wav")
+		rules := []exs.PathRule{{From: "/Users/bob/Music/Audio Music Apps/Samples", To: filepath.Join(root, "library", "Samples")}}
+		r, err := exs.NewSampleResolver(rules)
+		Expect(err).ToNot(HaveOccurred())
+		got, err := r.Resolve(&exs.Sample{FileName: "C3.wav", Path: "/Users/bob/Music/Audio Music Apps/Samples/Piano"}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(want))
+	})
+
+	It("should understand HFS paths", func() {
+		want := touch("old", "Piano", "C3.wav")
+		rules := []exs.PathRule{{From: "/Volumes/Samples HD/Piano", To: filepath.Join(root, "old", "Piano")}}
+		r, err := exs.NewSampleResolver(rules)
+		Expect(err).ToNot(HaveOccurred())
+		got, err := r.Resolve(&exs.Sample{FileName: "C3.wav", Path: "Samples HD:Piano:"}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(want))
+	})
+
+	It("should find samples relative to the exs file", func() {
+		exsPath := touch("Library", "Strings.exs")
+		want := touch("Library", "Strings", "Violin", "C3.wav")
+		r, err := exs.NewSampleResolver(nil)
+		Expect(err).ToNot(HaveOccurred())
+		got, err := r.Resolve(&exs.Sample{FileName: "C3.wav", Path: "/Volumes/Old/Library/Strings/Violin"}, exsPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(want))
+
+		got, err = r.Resolve(&exs.Sample{FileName: "C3.wav", Path: "Strings/Violin"}, exsPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(want))
+	})
+
+	It("should pick the indexed file matching the stored directories", func() {
+		touch("a", "Piano", "C3.wav")
+		want := touch("b", "Strings", "C3.wav")
+		touch("c", "Organ", "C3.wav")
+		r, err := exs.NewSampleResolver(nil, root)
+		Expect(err).ToNot(HaveOccurred())
+		got, err := r.Resolve(&exs.Sample{FileName: "C3.wav", Path: "/Users/bob/Samples/Strings"}, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(Equal(want))
+	})
+
+	It("should report missing samples", func() {
+		r, err := exs.NewSampleResolver(nil, root)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = r.Resolve(&exs.Sample{FileName: "C3.wav", Path: "/nowhere"}, "")
+		Expect(errors.Is(err, exs.ErrSampleNotFound)).To(BeTrue())
+	})
+})
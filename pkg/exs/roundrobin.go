@@ -0,0 +1,114 @@
+package exs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// Round Robin
+// ============================================================================
+
+// RoundRobinMember is a group of a RoundRobinSet.
+type RoundRobinMember struct {
+	Group    int // zero based group index
+	Position int // one based position in the playback order
+}
+
+// RoundRobinSet is a chain of groups that play in turn. Each group links to
+// the group it plays after through ExsGroup.SelectGroup.
+type RoundRobinSet struct {
+	Members []RoundRobinMember // in playback order
+	Cyclic  bool               // the first group links back to the last
+}
+
+// Position returns the one based position of group in the set, or 0 if the
+// group is not a member.
+func (s RoundRobinSet) Position(group int) int {
+	for _, m := range s.Members {
+		if m.Group == group {
+			return m.Position
+		}
+	}
+	return 0
+}
+
+func (s RoundRobinSet) String() string {
+	groups := make([]string, len(s.Members))
+	for i, m := range s.Members {
+		groups[i] = fmt.Sprint(m.Group)
+	}
+	if s.Cyclic {
+		groups = append(groups, groups[0])
+	}
+	return strings.Join(groups, " -> ")
+}
+
+// RoundRobinSets returns the round robin sets of the instrument, made of
+// groups linked through ExsGroup.SelectGroup. A group linking to itself is
+// not linked. Groups that link to a missing group, and groups that play
+// after a group another group already follows, are reported as findings and
+// start a new set instead. Rings of linked groups are returned as cyclic
+// sets starting at their lowest group index.
+func (exs *EXS) RoundRobinSets() ([]RoundRobinSet, []Finding) {
+	var findings []Finding
+	warn := func(format string, args ...interface{}) {
+		findings = append(findings, Finding{Severity: SeverityWarning, Zone: -1, Message: fmt.Sprintf(format, args...)})
+	}
+
+	n := len(exs.Groups)
+	next := make([]int, n) // group playing after each group, -1 if none
+	prev := make([]int, n) // group each group plays after, -1 if none
+	for i := range next {
+		next[i], prev[i] = -1, -1
+	}
+	for i, group := range exs.Groups {
+		p := int(group.SelectGroup)
+		switch {
+		case p == -1 || p == i:
+			// no link, Logic stores 0 in the first group
+		case p < 0 || p >= n:
+			warn("group %d %q plays after missing group %d", i, group.Name, p)
+		case next[p] != -1:
+			warn("groups %d and %d both play after group %d", next[p], i, p)
+		default:
+			next[p], prev[i] = i, p
+		}
+	}
+
+	var sets []RoundRobinSet
+	visited := make([]bool, n)
+	walk := func(start int, cyclic bool) {
+		set := RoundRobinSet{Cyclic: cyclic}
+		for g := start; g != -1 && !visited[g]; g = next[g] {
+			visited[g] = true
+			set.Members = append(set.Members, RoundRobinMember{Group: g, Position: len(set.Members) + 1})
+		}
+		if len(set.Members) > 1 {
+			sets = append(sets, set)
+		}
+	}
+	for i := range exs.Groups {
+		if prev[i] == -1 {
+			walk(i, false)
+		}
+	}
+	// every group left over has a predecessor, so it is part of a ring
+	for i := range exs.Groups {
+		if !visited[i] {
+			walk(i, true)
+		}
+	}
+	return sets, findings
+}
+
+// RoundRobinSet returns the set group belongs to.
+func (exs *EXS) RoundRobinSet(group int) (RoundRobinSet, bool) {
+	sets, _ := exs.RoundRobinSets()
+	for _, s := range sets {
+		if s.Position(group) != 0 {
+			return s, true
+		}
+	}
+	return RoundRobinSet{}, false
+}
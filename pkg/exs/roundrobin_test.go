@@ -0,0 +1,65 @@
+package exs_test
+
+import (
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RoundRobin", func() {
+	// linked returns an instrument whose groups play after the given groups.
+	linked := func(after ...int32) *exs.EXS {
+		e := &exs.EXS{}
+		for _, a := range after {
+			e.Groups = append(e.Groups, &exs.Group{ExsGroup: exs.ExsGroup{SelectGroup: a}})
+		}
+		return e
+	}
+
+	It("should list chains in playback order", func() {
+		sets, findings := linked(-1, 2, 0, -1).RoundRobinSets()
+		Expect(findings).To(BeEmpty())
+		Expect(sets).To(HaveLen(1))
+		Expect(sets[0].Cyclic).To(BeFalse())
+		Expect(sets[0].Members).To(Equal([]exs.RoundRobinMember{
+			{Group: 0, Position: 1},
+			{Group: 2, Position: 2},
+			{Group: 1, Position: 3},
+		}))
+		Expect(sets[0].Position(1)).To(Equal(3))
+		Expect(sets[0].Position(3)).To(Equal(0))
+		Expect(sets[0].String()).To(Equal("0 -> 2 -> 1"))
+	})
+
+	It("should detect cycles", func() {
+		sets, findings := linked(-1, 3, 1, 2).RoundRobinSets()
+		Expect(findings).To(BeEmpty())
+		Expect(sets).To(HaveLen(1))
+		Expect(sets[0].Cyclic).To(BeTrue())
+		Expect(sets[0].String()).To(Equal("1 -> 2 -> 3 -> 1"))
+	})
+
+	It("should report dangling and branching links", func() {
+		sets, findings := linked(-1, 0, 0, 9).RoundRobinSets()
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Message).To(Equal("groups 1 and 2 both play after group 0"))
+		Expect(findings[1].Message).To(Equal(`group 3 "" plays after missing group 9`))
+		Expect(sets).To(HaveLen(1))
+		Expect(sets[0].String()).To(Equal("0 -> 1"))
+
+		e := linked(-1, 0, 0, 9)
+		Expect(e.Validate()).To(HaveLen(2))
+	})
+
+	It("should not change SelectNumber when decoding", func() {
+		e, err := exs.NewFromFile("testdata/K3 Big.exs")
+		Expect(err).To(BeNil())
+		for _, g := range e.Groups {
+			Expect(g.SelectNumber).To(Equal(g.Raw[169]))
+		}
+		set, ok := e.RoundRobinSet(1)
+		Expect(ok).To(BeTrue())
+		Expect(set.Position(0)).To(Equal(1))
+		Expect(set.Position(1)).To(Equal(2))
+	})
+})
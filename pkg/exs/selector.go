@@ -0,0 +1,141 @@
+package exs
+
+import "fmt"
+
+// ============================================================================
+// Group Selectors
+// ============================================================================
+
+// SelectType is the MIDI event that switches a group on, as stored in
+// ExsGroup.SelectType.
+type SelectType uint8
+
+// Group select types.
+const (
+	SelectNone         SelectType = 0
+	SelectNote         SelectType = 1 // keyswitch
+	SelectGroup        SelectType = 2 // another group playing
+	SelectControl      SelectType = 3 // MIDI controller value
+	SelectBend         SelectType = 4 // pitch bend value
+	SelectChannel      SelectType = 5 // MIDI channel
+	SelectArticulation SelectType = 6 // articulation ID
+	SelectTempo        SelectType = 7 // host tempo
+)
+
+var selectTypeNames = map[SelectType]string{
+	SelectNone:         "--",
+	SelectNote:         "Note",
+	SelectGroup:        "Group",
+	SelectControl:      "Control",
+	SelectBend:         "Bend",
+	SelectChannel:      "MIDI Channel",
+	SelectArticulation: "Articulation ID",
+	SelectTempo:        "Tempo",
+}
+
+func (t SelectType) String() string {
+	if name, ok := selectTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("SelectType(%d)", uint8(t))
+}
+
+// Selector tells when a group is switched on.
+type Selector struct {
+	Type   SelectType
+	Number uint8 // controller number, group or articulation ID, depending on Type
+	Low    uint8 // low end of the note, value, channel or tempo range
+	High   uint8 // high end of the range
+}
+
+// newSelector reads the selector stored in exsGroup. The other fields are
+// left zero if the group has no select type, as they hold stale values then.
+func newSelector(exsGroup *ExsGroup) Selector {
+	if SelectType(exsGroup.SelectType) == SelectNone {
+		return Selector{}
+	}
+	return Selector{
+		Type:   SelectType(exsGroup.SelectType),
+		Number: exsGroup.SelectNumber,
+		Low:    exsGroup.SelectLow,
+		High:   exsGroup.SelectHigh,
+	}
+}
+
+// put stores the selector in exsGroup. An unchanged selector keeps the
+// fields it was read with, stale values included, so files encode back as
+// they were.
+func (s Selector) put(exsGroup *ExsGroup) {
+	if s == newSelector(exsGroup) {
+		return
+	}
+	exsGroup.SelectType = uint8(s.Type)
+	exsGroup.SelectNumber = s.Number
+	exsGroup.SelectLow = s.Low
+	exsGroup.SelectHigh = s.High
+}
+
+// Active reports whether the group is switched by a selector rather than
+// always playing.
+func (s Selector) Active() bool {
+	return s.Type != SelectNone
+}
+
+// IsKeyswitch reports whether the group is switched by a note.
+func (s Selector) IsKeyswitch() bool {
+	return s.Type == SelectNote
+}
+
+// Range returns the selector range with low <= high. Logic files store the
+// two ends in either order.
+func (s Selector) Range() (low, high uint8) {
+	if s.Low > s.High {
+		return s.High, s.Low
+	}
+	return s.Low, s.High
+}
+
+// Matches reports whether value, a note, controller value, bend value,
+// channel, tempo or articulation ID depending on Type, switches the group
+// on. A group without a selector matches every value.
+func (s Selector) Matches(value uint8) bool {
+	switch s.Type {
+	case SelectNone:
+		return true
+	case SelectGroup, SelectArticulation:
+		return value == s.Number
+	default:
+		low, high := s.Range()
+		return value >= low && value <= high
+	}
+}
+
+func (s Selector) String() string {
+	low, high := s.Range()
+	switch s.Type {
+	case SelectNone:
+		return "always"
+	case SelectNote:
+		return fmt.Sprintf("keyswitch %s–%s", noteName(low), noteName(high))
+	case SelectGroup:
+		return fmt.Sprintf("group %d", s.Number)
+	case SelectControl:
+		return fmt.Sprintf("CC%d %d–%d", s.Number, low, high)
+	case SelectBend:
+		return fmt.Sprintf("bend %d–%d", low, high)
+	case SelectChannel:
+		return fmt.Sprintf("MIDI channel %d–%d", low, high)
+	case SelectArticulation:
+		return fmt.Sprintf("articulation %d", s.Number)
+	case SelectTempo:
+		return fmt.Sprintf("tempo %d–%d", low, high)
+	default:
+		return fmt.Sprintf("%s %d %d–%d", s.Type, s.Number, low, high)
+	}
+}
+
+// noteName returns the name of a MIDI note as Logic shows it, 60 is C3.
+func noteName(note uint8) string {
+	names := []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	return fmt.Sprintf("%s%d", names[note%12], int(note)/12-2)
+}
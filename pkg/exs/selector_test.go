@@ -0,0 +1,68 @@
+package exs_test
+
+import (
+	"bytes"
+
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Selector", func() {
+	It("should describe each select type", func() {
+		Expect(exs.Selector{}.String()).To(Equal("always"))
+		Expect(exs.Selector{Type: exs.SelectNote, Low: 24, High: 35}.String()).To(Equal("keyswitch C0–B0"))
+		Expect(exs.Selector{Type: exs.SelectControl, Number: 1, Low: 0, High: 63}.String()).To(Equal("CC1 0–63"))
+		Expect(exs.Selector{Type: exs.SelectArticulation, Number: 3}.String()).To(Equal("articulation 3"))
+		Expect(exs.Selector{Type: exs.SelectTempo, Low: 140, High: 60}.String()).To(Equal("tempo 60–140"))
+		Expect(exs.SelectChannel.String()).To(Equal("MIDI Channel"))
+	})
+
+	It("should match values in range", func() {
+		s := exs.Selector{Type: exs.SelectNote, Low: 24, High: 35}
+		Expect(s.Active()).To(BeTrue())
+		Expect(s.IsKeyswitch()).To(BeTrue())
+		Expect(s.Matches(24)).To(BeTrue())
+		Expect(s.Matches(36)).To(BeFalse())
+
+		a := exs.Selector{Type: exs.SelectArticulation, Number: 3}
+		Expect(a.Matches(3)).To(BeTrue())
+		Expect(a.Matches(4)).To(BeFalse())
+		Expect(exs.Selector{}.Matches(99)).To(BeTrue())
+	})
+
+	It("should decode and encode group selectors", func() {
+		original, err := exs.NewFromFile("testdata/Analog Strings - Kawaii Dreams From Mars.exs")
+		Expect(err).To(BeNil())
+		Expect(original.Groups[0].Selector.Active()).To(BeFalse())
+
+		original.Groups[0].Selector = exs.Selector{Type: exs.SelectControl, Number: 1, Low: 64, High: 127}
+		var buf bytes.Buffer
+		Expect(exs.Encode(&buf, original)).To(Succeed())
+		decoded, err := exs.NewFromReader(bytes.NewReader(buf.Bytes()), original.Name)
+		Expect(err).To(BeNil())
+		Expect(decoded.Groups[0].Selector).To(Equal(original.Groups[0].Selector))
+		Expect(decoded.Groups[0].Selector.String()).To(Equal("CC1 64–127"))
+	})
+
+	It("should clear group selectors", func() {
+		original, err := exs.NewFromFile("testdata/Analog Strings - Kawaii Dreams From Mars.exs")
+		Expect(err).To(BeNil())
+		original.Groups[0].Selector = exs.Selector{Type: exs.SelectNote, Low: 24, High: 35}
+		var buf bytes.Buffer
+		Expect(exs.Encode(&buf, original)).To(Succeed())
+		switched, err := exs.NewFromReader(bytes.NewReader(buf.Bytes()), original.Name)
+		Expect(err).To(BeNil())
+		Expect(switched.Groups[0].Selector.IsKeyswitch()).To(BeTrue())
+
+		switched.Groups[0].Selector = exs.Selector{}
+		buf.Reset()
+		Expect(exs.Encode(&buf, switched)).To(Succeed())
+		cleared, err := exs.NewFromReader(bytes.NewReader(buf.Bytes()), original.Name)
+		Expect(err).To(BeNil())
+		Expect(cleared.Groups[0].Selector.Active()).To(BeFalse())
+		Expect(cleared.Groups[0].ExsGroup.SelectType).To(BeZero())
+		Expect(cleared.Groups[0].ExsGroup.SelectLow).To(BeZero())
+		Expect(cleared.Groups[0].ExsGroup.SelectHigh).To(BeZero())
+	})
+})
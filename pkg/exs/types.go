@@ -7,6 +7,7 @@ package exs
 // EXS represents a parsed Logic Pro EXS24 sampler instrument file.
 type EXS struct {
 	Name           string
+	FilePath       string // file the instrument was read from, empty if read from a reader
 	BigEndian      bool
 	IsSizeExpanded bool
 	Size           int
@@ -14,8 +15,17 @@ type EXS struct {
 	Groups         []*Group
 	Samples        []*Sample
 	Params         *Params
-	Sequences      [][]int32
 	Instrument     *ExsInstrument
+	Plist          Plist      // decoded plist chunk, nil if the file has none
+	Raw            []byte     // header chunk as read, nil for instruments built in code
+	Extra          []RawChunk // chunks other than header, zone, group, sample and options, in file order
+}
+
+// RawChunk is a chunk kept as read from the file.
+type RawChunk struct {
+	Type   uint32
+	Offset int64  // byte offset of the chunk header
+	Data   []byte // chunk header and payload
 }
 
 // Zone represents a zone in the EXS24 file.
@@ -31,21 +41,25 @@ type Zone struct {
 	LoopEndRelease  bool
 	PlayMode        uint8
 	HasOutput       bool
+	Raw             []byte // chunk as read, nil for zones built in code
 }
 
 // Group represents a group in the EXS24 file.
 type Group struct {
 	ExsGroup
-	Name  string
-	Decay bool
+	Name     string
+	Decay    bool
+	Selector Selector // when the group is switched on
+	Raw      []byte   // chunk as read, nil for groups built in code
 }
 
 // Sample represents a sample in the EXS24 file.
 type Sample struct {
-	ExsSample
-	Name     string
-	FileName string
-	Path     string
+	ExsSample // Name, FileName and Path hold the stored bytes, MacRoman in older files
+	Name      string
+	FileName  string
+	Path      string
+	Raw       []byte // chunk as read, nil for samples built in code
 }
 
 // Params represents the parsed parameters from ExsParams.
@@ -53,16 +67,16 @@ type Params struct {
 	// Global
 	OutputVolume  int16 ///< [-60,0]dB default:-6
 	KeyScale      int16 ///< [-24,24]dB default:0
-	PitchBendUp   int16 ///< [-12,12]cent default:0
-	PitchBendDown int16 ///< [-12,12]cent default:0
-	MonoMode      int16 ///< 0:off 1:on 2: legato
+	PitchBendUp   int16 ///< [0,12]semitones default:2
+	PitchBendDown int16 ///< [-1,12]semitones default:-1 @see: PitchBendLinked
+	MonoMode      int16 ///< 0:off 1:on 2: legato @see: MonoOff
 	Voices        int16 ///< [1,64] default:16
 	Unison        bool
 	// Start via Vel
-	Transpose     int16
-	CoarseTune    int16
-	FineTune      int16
-	GlideTime     int16
+	Transpose     int16 // [-36,36]semitones default:0, shifts the played notes
+	CoarseTune    int16 // [-24,24]semitones default:0
+	FineTune      int16 // [-50,50]cent default:0
+	GlideTime     int16 // [0,5000]ms default:0
 	Pitcher       int16
 	PitcherViaVel int16
 	// Pitch Mod. Wheel
@@ -79,11 +93,11 @@ type Params struct {
 	LevelViaVel int16 // [-96,0] for (-48dB~0dB) default:0   >=level_fixed;
 	// Tremolo
 	Lfo1DecayDelay int16 // [-9999,9999]ms negative:decay positive:delay
-	Lfo1Rate       int16 // [0,127] default:98(4.8Hz)
-	Lfo1Waveform   int16 // [0,6]
-	Lfo2Waveform   int16 // [0,6]
-	Lfo2Rate       int16 // [0,127] default:34(DC)
-	Lfo3Rate       int16 // [0,127] default:98(4.8Hz)
+	Lfo1Rate       int16 // [-16,127] default:98(4.8Hz) negative:tempo synced @see: LfoSynced
+	Lfo1Waveform   int16 // [0,6] @see: LfoTriangle
+	Lfo2Waveform   int16 // [0,6] @see: LfoTriangle
+	Lfo2Rate       int16 // [-16,127] default:34(DC) negative:tempo synced
+	Lfo3Rate       int16 // [-16,127] default:98(4.8Hz) negative:tempo synced
 	// Envelope
 	Env1Attack       int16 // [0,127] for (0~10000ms), <=env1_attack_via_vel @see: ym_exs_time_to_second()
 	Env1AttackViaVel int16 // [0,127] for (0~10000ms), >=env1_attack @see: ym_exs_time_to_second()
@@ -107,18 +121,63 @@ type Params struct {
 	VelocityXFade      int16 // [0,127] default:0
 	VelocityXFadeType  int16 //  0:dB lin 1:liner 2:Eq.Pow
 	CoarseTuneRemote   int16 // [-1,127] default:-1(OFF)
-	HoldVia            int16 // [-17,120	] default:64  @see: ym_exs_src_via_t
+	HoldVia            int16 // [-17,120	] default:64  @see: ModSource
 	SampleSelectRandom int16 // [0,127] default:0
 	RandomDetune       int16 // [0,50]cent default:0
 	// Modulator
-	Destination [10]int16 // [0,30] @see: ym_exs_dest_t
-	Source      [10]int16 // [-17,120]  @see: ym_exs_src_via_t
-	Via         [10]int16 // [-17,120]  @see: ym_exs_src_via_t
+	Destination [10]int16 // [0,30] @see: ModDestination
+	Source      [10]int16 // [-17,120]  @see: ModSource
+	Via         [10]int16 // [-17,120]  @see: ModSource
 	Amount      [10]int16 // [-1000,1000] for (-100%~100%)  <=amount_via
 	AmountVia   [10]int16 // [-1000,1000] for (-100%~100%)  >=amount
 	Invert      [10]bool
 	InvertVia   [10]bool
 	Bypass      [10]bool
+	// Keys lists the parameter keys present in the options chunk, in file
+	// order. Encode writes exactly these keys.
+	Keys []uint8
+	// Unknown holds the parameters that have no field above, in file order.
+	Unknown []Param
+	Raw     []byte // chunk as read, nil for params built in code
+}
+
+// Filter types, the values of Params.FilterType.
+const (
+	FilterLP12 int16 = 0
+	FilterLP18 int16 = 1
+	FilterLP24 int16 = 2
+	FilterLP6  int16 = 3
+	FilterBP12 int16 = 4
+	FilterHP12 int16 = 5
+)
+
+// PitchBendLinked is the PitchBendDown value that bends down as far as up.
+const PitchBendLinked int16 = -1
+
+// Voice modes, the values of Params.MonoMode.
+const (
+	MonoOff    int16 = 0
+	MonoOn     int16 = 1 // each note retriggers the envelopes
+	MonoLegato int16 = 2 // notes played legato glide without retriggering
+)
+
+// LFO waveforms, the values of Params.Lfo1Waveform and Lfo2Waveform, in the
+// order of the EXS24 waveform buttons. LFO 3 is always a triangle.
+const (
+	LfoTriangle     int16 = 0
+	LfoSawDown      int16 = 1
+	LfoSawUp        int16 = 2
+	LfoSquare       int16 = 3 // swings around zero
+	LfoSquareUp     int16 = 4 // positive only
+	LfoRandom       int16 = 5 // sample and hold
+	LfoSmoothRandom int16 = 6
+)
+
+// Param is a parameter key/value pair of the options chunk.
+type Param struct {
+	Key      uint16
+	Value    int16
+	Extended bool // stored in the extended table rather than the 100 fixed slots
 }
 
 // ============================================================================
@@ -251,6 +310,19 @@ type ExsParams struct {
 	Values [100]int16
 }
 
+// ExsParamsExtended represents the extended parameter table that follows
+// ExsParams in options chunks of 1108 bytes.
+type ExsParamsExtended struct {
+	_      [4]byte
+	Params [200]ExsParam // 392
+}
+
+// ExsParam represents a key/value pair of the extended parameter table.
+type ExsParam struct {
+	Key   uint16
+	Value int16
+}
+
 // ExsInstrument represents the binary structure of instrument metadata.
 type ExsInstrument struct {
 	_          [4]byte
@@ -0,0 +1,282 @@
+package exs
+
+import "math"
+
+// ============================================================================
+// Physical Units
+// ============================================================================
+
+// Ranges of the stored parameter values, as documented on Params. The
+// envelope time and LFO rate curves are not documented, the seconds and Hz
+// below are approximations, see ApproxEnvTimeSeconds and ApproxLfoRateHz.
+const (
+	maxEnvValue     = 127  // envelope times and levels
+	maxEnvSeconds   = 10.0 // documented envelope time at maxEnvValue
+	maxPercentValue = 1000 // filter and modulation percentages
+	maxLfoValue     = 127  // LFO rates
+	defaultLfoValue = 98   // LFO rate stored for defaultLfoHz
+	defaultLfoHz    = 4.8  // documented rate at defaultLfoValue
+	lfoOctaveSteps  = 14.0 // assumed LFO rate steps per doubling of the rate
+	lfo2DCValue     = 34   // LFO 2 rate that stops the LFO (DC)
+	levelDBScale    = 0.5  // dB per step of LevelFixed and LevelViaVel
+	maxCurveValue   = 99   // envelope and time curves
+	maxDecayDelayMs = 9999 // LFO 1 decay/delay
+	maxGlideMs      = 5000 // glide time
+	msPerSecond     = 1000.0
+
+	defaultOutputVolume = -6 // dB
+	defaultPitchBendUp  = 2  // semitones, bending down as far
+)
+
+// Keys of the parameters whose zero value is not their default.
+const (
+	pitchBendUpKey   = 3
+	pitchBendDownKey = 4
+	outputVolumeKey  = 7
+)
+
+// ApproxEnvTimeSeconds approximates the seconds of a stored envelope time of
+// [0,127]. Only the end points are documented, 0 is instant and 127 is 10
+// seconds. The curve between them is an assumed quartic, which keeps most of
+// the range for short times as the EXS24 envelope sliders do.
+func ApproxEnvTimeSeconds(v int16) float64 {
+	x := clampRange(float64(v), 0, maxEnvValue) / maxEnvValue
+	return maxEnvSeconds * x * x * x * x
+}
+
+// ApproxEnvTimeValue converts seconds back to a stored envelope time, the
+// inverse of ApproxEnvTimeSeconds.
+func ApproxEnvTimeValue(seconds float64) int16 {
+	x := math.Sqrt(math.Sqrt(clampRange(seconds, 0, maxEnvSeconds) / maxEnvSeconds))
+	return int16(math.Round(x * maxEnvValue))
+}
+
+// EnvLevel converts a stored envelope level of [0,127] to [0,1].
+func EnvLevel(v int16) float64 {
+	return clampRange(float64(v), 0, maxEnvValue) / maxEnvValue
+}
+
+// Percent converts a stored percentage of [0,1000] to [0,1].
+func Percent(v int16) float64 {
+	return clampRange(float64(v), 0, maxPercentValue) / maxPercentValue
+}
+
+// ApproxLfoRateHz approximates the Hz of a stored LFO rate of [0,127]. Only
+// the default of 98 (4.8 Hz) is documented. The rate is assumed to double
+// every 14 steps from it, which gives about 20 Hz at 127. Zero stops the
+// LFO, and synced rates have no fixed rate and return 0 too, see
+// LfoSynced.
+func ApproxLfoRateHz(v int16) float64 {
+	if v <= 0 {
+		return 0
+	}
+	v = int16(clampRange(float64(v), 0, maxLfoValue))
+	return defaultLfoHz * math.Pow(2, float64(v-defaultLfoValue)/lfoOctaveSteps)
+}
+
+// LfoSynced reports whether a stored LFO rate is synced to the song tempo,
+// the negative values left of the EXS24 rate knob. Which note value each of
+// -1 to -16 stands for is not documented, so none is returned.
+func LfoSynced(v int16) bool {
+	return v < 0
+}
+
+// Curve converts a stored envelope or time curve of [-99,99] to [-1,1].
+func Curve(v int16) float64 {
+	return clampUnit(float64(v) / maxCurveValue)
+}
+
+// clampRange limits v to [minimum, maximum].
+func clampRange(v, minimum, maximum float64) float64 {
+	return math.Max(minimum, math.Min(v, maximum))
+}
+
+// ============================================================================
+// Params Accessors
+// ============================================================================
+
+// OutputVolumeDB returns the master volume in dB, the default of -6 dB if
+// the instrument does not store it.
+func (params *Params) OutputVolumeDB() float64 {
+	if !params.stored(outputVolumeKey) {
+		return defaultOutputVolume
+	}
+	return float64(params.OutputVolume)
+}
+
+// KeyScaleDB returns the volume key scaling in dB.
+func (params *Params) KeyScaleDB() float64 {
+	return float64(params.KeyScale)
+}
+
+// LevelFixedDB returns the level at the lowest velocity in dB.
+func (params *Params) LevelFixedDB() float64 {
+	return float64(params.LevelFixed) * levelDBScale
+}
+
+// LevelViaVelDB returns the level at the highest velocity in dB.
+func (params *Params) LevelViaVelDB() float64 {
+	return float64(params.LevelViaVel) * levelDBScale
+}
+
+// FilterCutoffNormalized returns the filter cutoff in [0,1].
+func (params *Params) FilterCutoffNormalized() float64 {
+	return Percent(params.FilterCutoff)
+}
+
+// FilterResonanceNormalized returns the filter resonance in [0,1].
+func (params *Params) FilterResonanceNormalized() float64 {
+	return Percent(params.FilterResonance)
+}
+
+// FilterDriveNormalized returns the filter drive in [0,1].
+func (params *Params) FilterDriveNormalized() float64 {
+	return Percent(params.FilterDrive)
+}
+
+// FilterViaKeyNormalized returns the filter key tracking in [0,1].
+func (params *Params) FilterViaKeyNormalized() float64 {
+	return Percent(params.FilterViaKey)
+}
+
+// filterOnKey is the key FilterOn is stored under.
+const filterOnKey = 44
+
+// FilterSwitch returns whether the filter is switched on, and ok false if
+// the instrument does not store the switch, leaving it unknown. Params built
+// in code store it only when on, as Encode writes them.
+func (params *Params) FilterSwitch() (on, ok bool) {
+	if len(params.Keys) == 0 {
+		return params.FilterOn, params.FilterOn
+	}
+	if params.stored(filterOnKey) {
+		return params.FilterOn, true
+	}
+	return false, false
+}
+
+// stored reports whether the instrument stores the parameter key. Params
+// built in code have no Keys and store every field.
+func (params *Params) stored(key uint8) bool {
+	if len(params.Keys) == 0 {
+		return true
+	}
+	for _, k := range params.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Env1AttackSeconds returns the approximate filter envelope attack at the
+// lowest velocity.
+func (params *Params) Env1AttackSeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env1Attack)
+}
+
+// Env1AttackViaVelSeconds returns the approximate filter envelope attack at
+// the highest velocity.
+func (params *Params) Env1AttackViaVelSeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env1AttackViaVel)
+}
+
+// Env1DecaySeconds returns the approximate filter envelope decay.
+func (params *Params) Env1DecaySeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env1Decay)
+}
+
+// Env1SustainLevel returns the filter envelope sustain in [0,1].
+func (params *Params) Env1SustainLevel() float64 {
+	return EnvLevel(params.Env1Sustain)
+}
+
+// Env1ReleaseSeconds returns the approximate filter envelope release.
+func (params *Params) Env1ReleaseSeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env1Release)
+}
+
+// Env1AttackCurveNormalized returns the filter envelope attack curve in [-1,1].
+func (params *Params) Env1AttackCurveNormalized() float64 {
+	return Curve(params.Env1AttackCurve)
+}
+
+// Env2AttackSeconds returns the approximate amp envelope attack at the
+// lowest velocity.
+func (params *Params) Env2AttackSeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env2Attack)
+}
+
+// Env2AttackViaVelSeconds returns the approximate amp envelope attack at the
+// highest velocity.
+func (params *Params) Env2AttackViaVelSeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env2AttackViaVel)
+}
+
+// Env2DecaySeconds returns the approximate amp envelope decay.
+func (params *Params) Env2DecaySeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env2Decay)
+}
+
+// Env2SustainLevel returns the amp envelope sustain in [0,1].
+func (params *Params) Env2SustainLevel() float64 {
+	return EnvLevel(params.Env2Sustain)
+}
+
+// Env2ReleaseSeconds returns the approximate amp envelope release.
+func (params *Params) Env2ReleaseSeconds() float64 {
+	return ApproxEnvTimeSeconds(params.Env2Release)
+}
+
+// Env2AttackCurveNormalized returns the amp envelope attack curve in [-1,1].
+func (params *Params) Env2AttackCurveNormalized() float64 {
+	return Curve(params.Env2AttackCurve)
+}
+
+// TimeCurveNormalized returns the envelope time curve in [-1,1].
+func (params *Params) TimeCurveNormalized() float64 {
+	return Curve(params.TimeCurve)
+}
+
+// PitchBendSemitones returns how far the pitch bend wheel bends up and
+// down, in semitones. A range the instrument does not store has its
+// default: 2 semitones up, and down as far as up.
+func (params *Params) PitchBendSemitones() (up, down int) {
+	up = int(params.PitchBendUp)
+	if !params.stored(pitchBendUpKey) {
+		up = defaultPitchBendUp
+	}
+	if params.PitchBendDown == PitchBendLinked || !params.stored(pitchBendDownKey) {
+		return up, up
+	}
+	return up, int(math.Abs(float64(params.PitchBendDown)))
+}
+
+// GlideSeconds returns the glide time.
+func (params *Params) GlideSeconds() float64 {
+	return clampRange(float64(params.GlideTime), 0, maxGlideMs) / msPerSecond
+}
+
+// Lfo1RateHz returns the approximate rate of LFO 1.
+func (params *Params) Lfo1RateHz() float64 {
+	return ApproxLfoRateHz(params.Lfo1Rate)
+}
+
+// Lfo2RateHz returns the approximate rate of LFO 2, 0 if it is set to DC.
+func (params *Params) Lfo2RateHz() float64 {
+	if params.Lfo2Rate == lfo2DCValue {
+		return 0
+	}
+	return ApproxLfoRateHz(params.Lfo2Rate)
+}
+
+// Lfo3RateHz returns the approximate rate of LFO 3.
+func (params *Params) Lfo3RateHz() float64 {
+	return ApproxLfoRateHz(params.Lfo3Rate)
+}
+
+// Lfo1DecayDelaySeconds returns the LFO 1 fade time. Negative values fade
+// the LFO out, positive values fade it in.
+func (params *Params) Lfo1DecayDelaySeconds() float64 {
+	return clampRange(float64(params.Lfo1DecayDelay), -maxDecayDelayMs, maxDecayDelayMs) / msPerSecond
+}
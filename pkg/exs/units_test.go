@@ -0,0 +1,102 @@
+package exs_test
+
+import (
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Units", func() {
+	It("should approximate envelope times in seconds", func() {
+		Expect(exs.ApproxEnvTimeSeconds(0)).To(Equal(0.0))
+		Expect(exs.ApproxEnvTimeSeconds(127)).To(Equal(10.0))
+		Expect(exs.ApproxEnvTimeSeconds(200)).To(Equal(10.0))
+		Expect(exs.ApproxEnvTimeSeconds(64)).To(BeNumerically("~", 0.645, 0.001))
+		for v := int16(0); v <= 127; v++ {
+			Expect(exs.ApproxEnvTimeValue(exs.ApproxEnvTimeSeconds(v))).To(Equal(v))
+		}
+	})
+
+	It("should approximate LFO rates in Hz", func() {
+		Expect(exs.ApproxLfoRateHz(0)).To(Equal(0.0))
+		Expect(exs.ApproxLfoRateHz(98)).To(Equal(4.8))
+		Expect(exs.ApproxLfoRateHz(112)).To(BeNumerically("~", 9.6, 1e-9))
+		Expect(exs.ApproxLfoRateHz(127)).To(BeNumerically("~", 20, 0.5))
+
+		params := &exs.Params{Lfo1Rate: 98, Lfo2Rate: 34, Lfo3Rate: 84}
+		Expect(params.Lfo1RateHz()).To(Equal(4.8))
+		Expect(params.Lfo2RateHz()).To(Equal(0.0))
+		Expect(params.Lfo3RateHz()).To(BeNumerically("~", 2.4, 1e-9))
+	})
+
+	It("should tell tempo synced LFO rates", func() {
+		Expect(exs.LfoSynced(98)).To(BeFalse())
+		Expect(exs.LfoSynced(0)).To(BeFalse())
+		Expect(exs.LfoSynced(-1)).To(BeTrue())
+		Expect(exs.LfoSynced(-16)).To(BeTrue())
+		Expect(exs.ApproxLfoRateHz(-9)).To(Equal(0.0))
+	})
+
+	It("should convert the Params ranges", func() {
+		params := &exs.Params{
+			OutputVolume:    -6,
+			LevelFixed:      -96,
+			FilterCutoff:    1000,
+			FilterResonance: 250,
+			Env2Sustain:     127,
+			Env1Sustain:     -1,
+			TimeCurve:       -99,
+			Lfo1DecayDelay:  -1500,
+			GlideTime:       6000,
+		}
+		Expect(params.OutputVolumeDB()).To(Equal(-6.0))
+		Expect(params.LevelFixedDB()).To(Equal(-48.0))
+		Expect(params.FilterCutoffNormalized()).To(Equal(1.0))
+		Expect(params.FilterResonanceNormalized()).To(Equal(0.25))
+		Expect(params.Env2SustainLevel()).To(Equal(1.0))
+		Expect(params.Env1SustainLevel()).To(Equal(0.0))
+		Expect(params.TimeCurveNormalized()).To(Equal(-1.0))
+		Expect(params.Lfo1DecayDelaySeconds()).To(Equal(-1.5))
+		Expect(params.GlideSeconds()).To(Equal(5.0))
+	})
+
+	It("should link the pitch bend down range to the up range", func() {
+		up, down := (&exs.Params{PitchBendUp: 2, PitchBendDown: exs.PitchBendLinked}).PitchBendSemitones()
+		Expect([]int{up, down}).To(Equal([]int{2, 2}))
+		up, down = (&exs.Params{PitchBendUp: 2, PitchBendDown: 12}).PitchBendSemitones()
+		Expect([]int{up, down}).To(Equal([]int{2, 12}))
+	})
+
+	It("should use the defaults of ranges the instrument does not store", func() {
+		params := &exs.Params{Keys: []uint8{44}}
+		Expect(params.OutputVolumeDB()).To(Equal(-6.0))
+		up, down := params.PitchBendSemitones()
+		Expect([]int{up, down}).To(Equal([]int{2, 2}))
+
+		params = &exs.Params{Keys: []uint8{3, 7}, PitchBendUp: 7}
+		Expect(params.OutputVolumeDB()).To(Equal(0.0))
+		up, down = params.PitchBendSemitones()
+		Expect([]int{up, down}).To(Equal([]int{7, 7}))
+	})
+
+	It("should tell whether the filter switch is stored", func() {
+		on, ok := (&exs.Params{Keys: []uint8{44}, FilterOn: true}).FilterSwitch()
+		Expect(on && ok).To(BeTrue())
+		on, ok = (&exs.Params{Keys: []uint8{44}}).FilterSwitch()
+		Expect(on).To(BeFalse())
+		Expect(ok).To(BeTrue())
+		_, ok = (&exs.Params{Keys: []uint8{46}}).FilterSwitch()
+		Expect(ok).To(BeFalse())
+		on, ok = (&exs.Params{FilterOn: true}).FilterSwitch()
+		Expect(on && ok).To(BeTrue())
+
+		instrument, err := exs.NewFromFile("testdata/Analog Strings - Kawaii Dreams From Mars.exs")
+		Expect(err).To(BeNil())
+		on, ok = instrument.Params.FilterSwitch()
+		Expect(on && ok).To(BeTrue())
+		instrument, err = exs.NewFromFile("testdata/DMX From Mars - Dirty Color Kit.exs")
+		Expect(err).To(BeNil())
+		_, ok = instrument.Params.FilterSwitch()
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -4,38 +4,44 @@ import (
 	"bytes"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 func getString64(b [64]byte) string {
-	bt := bytes.Trim(b[:], "\x00")
-	if len(bt) == 0 {
-		return ""
-	}
-	if bt[len(bt)-1] == 0 {
-		return string(b[:len(b)-1])
-	}
-	return strings.Map(func(r rune) rune {
-		if unicode.IsPrint(r) {
-			return r
-		}
-		return -1
-	}, string(bt))
+	return decodeString(b[:])
 }
 
 func getString256(b [256]byte) string {
-	bt := bytes.Trim(b[:], "\x00")
-	if len(bt) == 0 {
-		return ""
-	}
-	if bt[len(bt)-1] == 0 {
-		return string(b[:len(b)-1])
+	return decodeString(b[:])
+}
+
+// decodeString decodes a NUL terminated name or path field. Fields are UTF-8
+// in files saved by current versions of Logic and MacRoman in files saved on
+// classic Mac OS; anything that is not valid UTF-8 is read as MacRoman.
+// Control characters are dropped, everything else is kept, including the
+// no-break space and the private use Apple logo of MacRoman.
+func decodeString(b []byte) string {
+	b = cString(b)
+	s := string(b)
+	if !utf8.Valid(b) {
+		s = decodeMacRoman(b)
 	}
 	return strings.Map(func(r rune) rune {
-		if unicode.IsPrint(r) {
-			return r
+		if unicode.IsControl(r) {
+			return -1
 		}
-		return -1
-	}, string(bt))
+		return r
+	}, s)
+}
+
+// cString returns the bytes of b up to the first NUL, skipping leading NULs.
+// Logic leaves stale bytes of longer, earlier names after the terminator.
+func cString(b []byte) []byte {
+	b = bytes.TrimLeft(b, "\x00")
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return b
 }
 
 func twosComplement(value int8, bits int) int8 {
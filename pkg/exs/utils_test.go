@@ -42,6 +42,42 @@ func TestGetString64(t *testing.T) {
 			}(),
 			expected: "test",
 		},
+		{
+			name: "stale bytes after the terminator",
+			input: func() [64]byte {
+				var b [64]byte
+				copy(b[:], "Dirty Color\x00Color")
+				return b
+			}(),
+			expected: "Dirty Color",
+		},
+		{
+			name: "UTF-8 string",
+			input: func() [64]byte {
+				var b [64]byte
+				copy(b[:], "Célesta")
+				return b
+			}(),
+			expected: "Célesta",
+		},
+		{
+			name: "MacRoman string",
+			input: func() [64]byte {
+				var b [64]byte
+				copy(b[:], "C\x8elesta \xa5 F\x9fr Elise")
+				return b
+			}(),
+			expected: "Célesta • Für Elise",
+		},
+		{
+			name: "MacRoman no-break space and Apple logo",
+			input: func() [64]byte {
+				var b [64]byte
+				copy(b[:], "\xf0\xcaPiano\x7f")
+				return b
+			}(),
+			expected: "\uf8ff\u00a0Piano",
+		},
 	}
 
 	for _, tt := range tests {
@@ -137,3 +173,31 @@ func TestTwosComplement(t *testing.T) {
 		})
 	}
 }
+
+func TestSetString64(t *testing.T) {
+	var b [64]byte
+	copy(b[:], "C\x8elesta")
+
+	setString64(&b, "Célesta")
+	if string(b[:8]) != "C\x8elesta\x00" {
+		t.Errorf("setString64() rewrote an unchanged field: %q", b[:8])
+	}
+
+	setString64(&b, "Célesta 2")
+	if string(b[:10]) != "C\x8elesta 2\x00" {
+		t.Errorf("setString64() = %q, want MacRoman", b[:10])
+	}
+
+	setString64(&b, "Célesta ☃")
+	if got := getString64(b); got != "Célesta ☃" {
+		t.Errorf("setString64() = %q, want UTF-8 fallback", got)
+	}
+}
+
+func TestPutStringTruncatesOnRuneBoundary(t *testing.T) {
+	b := make([]byte, 4)
+	putString(b, "abé", false)
+	if string(b) != "ab\x00\x00" {
+		t.Errorf("putString() = %q", b)
+	}
+}
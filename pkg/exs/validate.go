@@ -0,0 +1,136 @@
+package exs
+
+import "fmt"
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// Severity tells how serious a Finding is.
+type Severity int
+
+const (
+	// SeverityWarning marks data that loads but is likely not what was meant.
+	SeverityWarning Severity = iota
+	// SeverityError marks data that cannot be played or converted as stored.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Finding is a structural problem reported by Validate.
+type Finding struct {
+	Severity Severity
+	Zone     int // zero based zone index, -1 if the finding is not about a zone
+	Message  string
+}
+
+func (f Finding) String() string {
+	if f.Zone >= 0 {
+		return fmt.Sprintf("%s: zone %d: %s", f.Severity, f.Zone, f.Message)
+	}
+	return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+}
+
+// HasErrors reports whether findings holds a finding of SeverityError.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the instrument for structural problems: counts that
+// disagree with the header, zones that point at missing samples or groups,
+// inverted key and velocity ranges, loops outside the played sample range,
+// zones of the same group that overlap and broken round robin links. It
+// returns nil if there are none.
+func (exs *EXS) Validate() []Finding {
+	var findings []Finding
+	add := func(severity Severity, zone int, format string, args ...interface{}) {
+		findings = append(findings, Finding{Severity: severity, Zone: zone, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if exs.Instrument != nil {
+		counts := []struct {
+			name   string
+			header uint32
+			read   int
+		}{
+			{"zones", exs.Instrument.NumZones, len(exs.Zones)},
+			{"groups", exs.Instrument.NumGroups, len(exs.Groups)},
+			{"samples", exs.Instrument.NumSamples, len(exs.Samples)},
+		}
+		for _, c := range counts {
+			if int(c.header) != c.read {
+				add(SeverityError, -1, "header counts %d %s, file holds %d", c.header, c.name, c.read)
+			}
+		}
+	}
+
+	for i, zone := range exs.Zones {
+		switch {
+		case zone.SampleIndex == -1:
+			add(SeverityWarning, i, "%q has no sample", zone.Name)
+		case zone.SampleIndex < 0 || int(zone.SampleIndex) >= len(exs.Samples):
+			add(SeverityError, i, "%q points at sample %d of %d", zone.Name, zone.SampleIndex, len(exs.Samples))
+		}
+		if zone.GroupIndex < -1 || int(zone.GroupIndex) >= len(exs.Groups) {
+			add(SeverityError, i, "%q points at group %d of %d", zone.Name, zone.GroupIndex, len(exs.Groups))
+		}
+		if zone.KeyLow > zone.KeyHigh {
+			add(SeverityError, i, "%q has inverted key range %d-%d", zone.Name, zone.KeyLow, zone.KeyHigh)
+		}
+		if zone.VelLow > zone.VelHigh {
+			add(SeverityError, i, "%q has inverted velocity range %d-%d", zone.Name, zone.VelLow, zone.VelHigh)
+		}
+		if zone.LoopOn {
+			if zone.LoopStart > zone.LoopEnd {
+				add(SeverityError, i, "%q has inverted loop %d-%d", zone.Name, zone.LoopStart, zone.LoopEnd)
+			} else if zone.LoopStart < zone.SampleStart || zone.LoopEnd > zone.SampleEnd {
+				add(SeverityError, i, "%q loops %d-%d outside sample range %d-%d",
+					zone.Name, zone.LoopStart, zone.LoopEnd, zone.SampleStart, zone.SampleEnd)
+			}
+		}
+	}
+
+	for i, a := range exs.Zones {
+		for j := i + 1; j < len(exs.Zones); j++ {
+			b := exs.Zones[j]
+			if a.GroupIndex == b.GroupIndex && a.overlaps(b) {
+				add(SeverityWarning, j, "%q overlaps zone %d %q in group %d", b.Name, i, a.Name, a.GroupIndex)
+			}
+		}
+	}
+	_, rrFindings := exs.RoundRobinSets()
+	return append(findings, rrFindings...)
+}
+
+// overlaps reports whether both zones play for some key and velocity.
+func (zone *Zone) overlaps(other *Zone) bool {
+	if zone.KeyLow > other.KeyHigh || other.KeyLow > zone.KeyHigh {
+		return false
+	}
+	lowA, highA := zone.velocityRange()
+	lowB, highB := other.velocityRange()
+	return lowA <= highB && lowB <= highA
+}
+
+// velocityRange returns the velocities the zone plays for.
+func (zone *Zone) velocityRange() (low, high int8) {
+	if !zone.VelocityRangeOn {
+		return 0, 127
+	}
+	return zone.VelLow, zone.VelHigh
+}
@@ -0,0 +1,79 @@
+package exs_test
+
+import (
+	"github.com/cldmnky/exsconvert/pkg/exs"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	// valid returns a small instrument without findings.
+	valid := func() *exs.EXS {
+		e := &exs.EXS{
+			Instrument: &exs.ExsInstrument{NumZones: 2, NumGroups: 1, NumSamples: 2},
+			Groups:     []*exs.Group{{Name: "Group"}},
+			Samples:    []*exs.Sample{{Name: "C3"}, {Name: "C4"}},
+			Zones: []*exs.Zone{
+				{Name: "C3", ExsZone: exs.ExsZone{KeyLow: 0, KeyHigh: 59, VelHigh: 127, SampleEnd: 1000, SampleIndex: 0}},
+				{Name: "C4", ExsZone: exs.ExsZone{KeyLow: 60, KeyHigh: 127, VelHigh: 127, SampleEnd: 1000, SampleIndex: 1}},
+			},
+		}
+		return e
+	}
+
+	It("should accept a valid instrument", func() {
+		Expect(valid().Validate()).To(BeEmpty())
+	})
+
+	It("should flag dangling sample and group indexes", func() {
+		e := valid()
+		e.Zones[0].SampleIndex = 5
+		e.Zones[1].GroupIndex = 3
+		findings := e.Validate()
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Zone).To(Equal(0))
+		Expect(findings[0].Severity).To(Equal(exs.SeverityError))
+		Expect(findings[1].Zone).To(Equal(1))
+		Expect(exs.HasErrors(findings)).To(BeTrue())
+	})
+
+	It("should flag inverted ranges and loops outside the sample", func() {
+		e := valid()
+		e.Zones[0].KeyLow, e.Zones[0].KeyHigh = 59, 0
+		e.Zones[1].VelLow, e.Zones[1].VelHigh = 100, 10
+		e.Zones[1].VelocityRangeOn = true
+		e.Zones[1].LoopOn = true
+		e.Zones[1].LoopStart, e.Zones[1].LoopEnd = 10, 2000
+		findings := e.Validate()
+		Expect(findings).To(HaveLen(3))
+		Expect(findings[0].String()).To(Equal(`error: zone 0: "C3" has inverted key range 59-0`))
+		Expect(findings[2].String()).To(Equal(`error: zone 1: "C4" loops 10-2000 outside sample range 0-1000`))
+	})
+
+	It("should warn about overlapping zones in the same group", func() {
+		e := valid()
+		e.Zones[1].KeyLow = 50
+		findings := e.Validate()
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Severity).To(Equal(exs.SeverityWarning))
+		Expect(exs.HasErrors(findings)).To(BeFalse())
+
+		e.Zones[0].VelocityRangeOn, e.Zones[0].VelHigh = true, 63
+		e.Zones[1].VelocityRangeOn, e.Zones[1].VelLow = true, 64
+		Expect(e.Validate()).To(BeEmpty())
+	})
+
+	It("should flag counts that disagree with the header", func() {
+		e := valid()
+		e.Instrument.NumSamples = 3
+		findings := e.Validate()
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].String()).To(Equal("error: header counts 3 samples, file holds 2"))
+	})
+
+	It("should find no errors in the test files", func() {
+		e, err := exs.NewFromFile("testdata/Big News (slow sweeps).exs")
+		Expect(err).To(BeNil())
+		Expect(exs.HasErrors(e.Validate())).To(BeFalse())
+	})
+})
@@ -0,0 +1,145 @@
+package xpm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ErrUnsupportedProgram is returned by Decode for programs that are neither
+// Drum nor Keygroup programs, such as Clip or Plugin programs.
+var ErrUnsupportedProgram = errors.New("unsupported program type")
+
+// utf8BOM is written by some editors in front of the XML declaration.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Load reads the MPC program stored at path.
+func Load(path string) (*MPCVObject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	xpm, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return xpm, nil
+}
+
+// Decode reads an MPC program from r.
+//
+// Programs written by the MPC differ from the ones NewXPMKeygroup and
+// NewXPMDrum create: firmware 2.10 and later name the pads element after the
+// JSON version it holds, e.g. ProgramPads-v2.10, and elements the firmware
+// does not need are left out and read as their zero values. The pads
+// element keeps its name, so Save writes it back the same way. Layer root
+// notes are stored as MIDI note + 1, see Layer.MIDIRootNote.
+func Decode(r io.Reader) (*MPCVObject, error) {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	var xpm MPCVObject
+	if err := xml.NewDecoder(br).Decode(&xpm); err != nil {
+		return nil, err
+	}
+	dropIndentation(reflect.ValueOf(&xpm).Elem())
+	switch xpm.Program.Type {
+	case TypeDrum, TypeKeygroup:
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedProgram, xpm.Program.Type)
+	}
+	return &xpm, nil
+}
+
+// dropIndentation clears the chardata fields below v that only hold the
+// indentation of the file, which Save would otherwise write back on top of
+// its own.
+func dropIndentation(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			dropIndentation(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			dropIndentation(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := v.Field(i)
+			if t.Field(i).Tag.Get("xml") == ",chardata" && field.Kind() == reflect.String {
+				if strings.TrimSpace(field.String()) == "" {
+					field.SetString("")
+				}
+				continue
+			}
+			dropIndentation(field)
+		}
+	}
+}
+
+// IsDrum reports whether the program is a Drum program, which maps pads to
+// notes through PadNoteMap.
+func (p *Program) IsDrum() bool {
+	return p.Type == TypeDrum
+}
+
+// IsKeygroup reports whether the program is a Keygroup program, which maps
+// notes to instruments through their LowNote and HighNote.
+func (p *Program) IsKeygroup() bool {
+	return p.Type == TypeKeygroup
+}
+
+// UnmarshalXML decodes a program, taking the pads from a versioned pads
+// element such as ProgramPads-v2.10 if there is no plain ProgramPads.
+func (p *Program) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type program Program // without this method
+	var shadow struct {
+		program
+		Other []ProgramPadsContent `xml:",any"`
+	}
+	if err := d.DecodeElement(&shadow, &start); err != nil {
+		return err
+	}
+	*p = Program(shadow.program)
+	for _, other := range shadow.Other {
+		if strings.HasPrefix(other.XMLName.Local, ProgramPads+"-") {
+			p.ProgramPads = other
+			break
+		}
+	}
+	return nil
+}
+
+// MarshalXML writes the pads under the element name they were read with, or
+// ProgramPads.
+func (c ProgramPadsContent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.XMLName.Local != "" {
+		start.Name = c.XMLName
+	}
+	raw := struct {
+		Content string `xml:",innerxml"`
+	}{c.Content}
+	return e.EncodeElement(raw, start)
+}
+
+// MIDIRootNote returns the MIDI note that plays the layer's sample at its
+// original pitch, or -1 if the layer has none. The MPC stores it as MIDI
+// note + 1, with 0 meaning unset.
+func (l *Layer) MIDIRootNote() int {
+	return l.RootNote - 1
+}
+
+// SetMIDIRootNote stores note, a MIDI note, as the layer's root note.
+func (l *Layer) SetMIDIRootNote(note int) {
+	l.RootNote = note + 1
+}
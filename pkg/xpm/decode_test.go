@@ -0,0 +1,97 @@
+package xpm_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+var _ = Describe("Decode", func() {
+	files, _ := filepath.Glob("testdata/*.xpm")
+
+	It("should have test files", func() {
+		Expect(files).ToNot(BeEmpty())
+	})
+
+	for _, file := range files {
+		file := file
+		It("should load "+filepath.Base(file)+" and load it again after saving", func() {
+			program, err := xpm.Load(file)
+			Expect(err).To(BeNil())
+			Expect(program.Program.IsDrum() || program.Program.IsKeygroup()).To(BeTrue())
+			Expect(program.Program.ProgramPads.Content).To(ContainSubstring("&quot;ProgramPads"))
+			if program.Program.IsDrum() {
+				Expect(program.Program.PadNoteMap).ToNot(BeNil())
+			}
+
+			saved := filepath.Join(GinkgoT().TempDir(), filepath.Base(file))
+			Expect(program.Save(saved)).To(Succeed())
+			reloaded, err := xpm.Load(saved)
+			Expect(err).To(BeNil())
+			Expect(reloaded.Program.ProgramPads).To(Equal(program.Program.ProgramPads))
+			Expect(reloaded.Program.Instruments.Instrument).To(HaveLen(len(program.Program.Instruments.Instrument)))
+			for i, instrument := range program.Program.Instruments.Instrument {
+				Expect(reloaded.Program.Instruments.Instrument[i].Layers.Layer).To(Equal(instrument.Layers.Layer))
+			}
+		})
+	}
+
+	It("should keep the versioned pads element of newer firmware", func() {
+		program, err := xpm.Load("testdata/Empty.xpm")
+		Expect(err).To(BeNil())
+		Expect(program.Program.ProgramPads.XMLName.Local).To(Equal("ProgramPads-v2.10"))
+
+		saved := filepath.Join(GinkgoT().TempDir(), "Empty.xpm")
+		Expect(program.Save(saved)).To(Succeed())
+		reloaded, err := xpm.Load(saved)
+		Expect(err).To(BeNil())
+		Expect(reloaded.Program.ProgramPads.XMLName.Local).To(Equal("ProgramPads-v2.10"))
+	})
+
+	It("should read the plain pads element of older firmware", func() {
+		program, err := xpm.Load("testdata/DMX.xpm")
+		Expect(err).To(BeNil())
+		Expect(program.Program.IsDrum()).To(BeTrue())
+		Expect(program.Program.ProgramPads.XMLName.Local).To(Equal("ProgramPads"))
+	})
+
+	It("should read root notes as MIDI note + 1", func() {
+		program, err := xpm.Load("testdata/example.xpm")
+		Expect(err).To(BeNil())
+		layer := program.Program.Instruments.Instrument[0].Layers.Layer[0]
+		Expect(layer.RootNote).To(Equal(61))
+		Expect(layer.MIDIRootNote()).To(Equal(60))
+
+		layer.SetMIDIRootNote(48)
+		Expect(layer.RootNote).To(Equal(49))
+	})
+
+	It("should read programs without optional elements", func() {
+		program, err := xpm.Decode(strings.NewReader("\xef\xbb\xbf" + `<?xml version="1.0" encoding="UTF-8"?>
+<MPCVObject>
+  <Program type="Keygroup">
+    <ProgramName>Minimal</ProgramName>
+  </Program>
+</MPCVObject>`))
+		Expect(err).To(BeNil())
+		Expect(program.Program.ProgramName).To(Equal("Minimal"))
+		Expect(program.Program.IsKeygroup()).To(BeTrue())
+		Expect(program.Program.PadNoteMap).To(BeNil())
+		Expect(program.Program.Instruments.Instrument).To(BeEmpty())
+	})
+
+	It("should reject programs other than Drum and Keygroup", func() {
+		_, err := xpm.Decode(strings.NewReader(`<MPCVObject><Program type="Clip"></Program></MPCVObject>`))
+		Expect(errors.Is(err, xpm.ErrUnsupportedProgram)).To(BeTrue())
+	})
+
+	It("should reject files that are not programs", func() {
+		_, err := xpm.Decode(strings.NewReader(`<plist><dict/></plist>`))
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,152 @@
+package xpm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Program Pads
+// ============================================================================
+
+// NumPads is the number of pads of a program, 8 banks of 16.
+const NumPads = 128
+
+// Pad colour modes stored in Pads.Type, as the MPC writes them.
+const (
+	PadColorsUniversal = 1 // every pad shows UniversalPad
+	PadColorsPerPad    = 2 // every pad shows its own colour
+)
+
+// DefaultPadColor is the colour the MPC uses for pads of new programs.
+const DefaultPadColor PadColor = 0x007F00
+
+// PadColor is a pad colour as 0xRRGGBB. Zero leaves the pad unlit.
+type PadColor uint32
+
+// RGB returns a PadColor from its red, green and blue components.
+func RGB(r, g, b uint8) PadColor {
+	return PadColor(r)<<16 | PadColor(g)<<8 | PadColor(b)
+}
+
+func (c PadColor) String() string {
+	return fmt.Sprintf("#%06X", uint32(c))
+}
+
+// Pads is the pad state the MPC stores as JSON in the ProgramPads
+// element of a program.
+type Pads struct {
+	Universal    bool              // all pads show UniversalPad
+	Type         int               // PadColorsUniversal or PadColorsPerPad
+	UniversalPad PadColor          // colour of all pads if Universal is set
+	Pads         [NumPads]PadColor // colour of each pad, pad A01 first
+	UnusedPads   int
+}
+
+// NewPads returns the pads of a new program, all showing
+// DefaultPadColor.
+func NewPads() *Pads {
+	return &Pads{
+		Universal:    true,
+		Type:         PadColorsUniversal,
+		UniversalPad: DefaultPadColor,
+		UnusedPads:   1,
+	}
+}
+
+// SetPadColor sets the colour of pad, zero based, and switches the program
+// to per pad colours. Pads that had none take the universal colour.
+func (p *Pads) SetPadColor(pad int, color PadColor) error {
+	if pad < 0 || pad >= NumPads {
+		return fmt.Errorf("pad %d out of range [0,%d)", pad, NumPads)
+	}
+	if p.Universal {
+		for i := range p.Pads {
+			if p.Pads[i] == 0 {
+				p.Pads[i] = p.UniversalPad
+			}
+		}
+		p.Universal = false
+		p.Type = PadColorsPerPad
+	}
+	p.Pads[pad] = color
+	return nil
+}
+
+// PadColor returns the colour pad, zero based, shows.
+func (p *Pads) PadColor(pad int) PadColor {
+	if p.Universal || pad < 0 || pad >= NumPads {
+		return p.UniversalPad
+	}
+	return p.Pads[pad]
+}
+
+// padsJSON is the JSON layout of Pads. The MPC stores arrays as
+// objects with value0, value1, ... keys.
+type padsJSON struct {
+	Universal    map[string]bool     `json:"Universal"`
+	Type         map[string]int      `json:"Type"`
+	UniversalPad PadColor            `json:"universalPad"`
+	Pads         map[string]PadColor `json:"pads"`
+	UnusedPads   map[string]int      `json:"UnusedPads"`
+}
+
+// Pads parses the pad state stored in the element.
+func (c ProgramPadsContent) Pads() (*Pads, error) {
+	var top map[string]padsJSON
+	if err := json.Unmarshal([]byte(html.UnescapeString(c.Content)), &top); err != nil {
+		return nil, fmt.Errorf("program pads: %w", err)
+	}
+	if len(top) != 1 {
+		return nil, fmt.Errorf("program pads: want one top level key, got %d", len(top))
+	}
+	var stored padsJSON
+	for _, v := range top {
+		stored = v
+	}
+	pads := &Pads{
+		Universal:    stored.Universal["value0"],
+		Type:         stored.Type["value0"],
+		UniversalPad: stored.UniversalPad,
+		UnusedPads:   stored.UnusedPads["value0"],
+	}
+	for key, color := range stored.Pads {
+		i, err := strconv.Atoi(strings.TrimPrefix(key, "value"))
+		if err != nil || i < 0 || i >= NumPads {
+			return nil, fmt.Errorf("program pads: unknown pad %q", key)
+		}
+		pads.Pads[i] = color
+	}
+	return pads, nil
+}
+
+// SetPads stores pads in the element, in the layout the MPC writes: JSON
+// indented by four spaces under a key named after the element, with the
+// quotes escaped.
+func (c *ProgramPadsContent) SetPads(pads *Pads) {
+	name := c.XMLName.Local
+	if name == "" {
+		name = ProgramPads
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "{\n    %q: {\n", name)
+	fmt.Fprintf(&b, "        \"Universal\": {\n            \"value0\": %t\n        },\n", pads.Universal)
+	fmt.Fprintf(&b, "        \"Type\": {\n            \"value0\": %d\n        },\n", pads.Type)
+	fmt.Fprintf(&b, "        \"universalPad\": %d,\n", pads.UniversalPad)
+	b.WriteString("        \"pads\": {\n")
+	for i, color := range pads.Pads {
+		sep := ","
+		if i == NumPads-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&b, "            \"value%d\": %d%s\n", i, color, sep)
+	}
+	b.WriteString("        },\n")
+	fmt.Fprintf(&b, "        \"UnusedPads\": {\n            \"value0\": %d\n        }\n", pads.UnusedPads)
+	b.WriteString("    }\n}")
+	c.Content = strings.ReplaceAll(b.String(), `"`, "&quot;")
+}
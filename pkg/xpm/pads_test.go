@@ -0,0 +1,66 @@
+package xpm_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+var _ = Describe("Pads", func() {
+	files, _ := filepath.Glob("testdata/*.xpm")
+
+	for _, file := range files {
+		file := file
+		It("should write the pads of "+filepath.Base(file)+" as the MPC does", func() {
+			program, err := xpm.Load(file)
+			Expect(err).To(BeNil())
+			pads, err := program.Program.ProgramPads.Pads()
+			Expect(err).To(BeNil())
+
+			written := program.Program.ProgramPads
+			written.SetPads(pads)
+			Expect(written.Content).To(Equal(program.Program.ProgramPads.Content))
+		})
+	}
+
+	It("should read the pad colours of a drum kit", func() {
+		program, err := xpm.Load("testdata/All purpose-Crunchy Kit.xpm")
+		Expect(err).To(BeNil())
+		pads, err := program.Program.ProgramPads.Pads()
+		Expect(err).To(BeNil())
+		Expect(pads.Universal).To(BeFalse())
+		Expect(pads.Type).To(Equal(xpm.PadColorsPerPad))
+		Expect(pads.PadColor(0)).To(Equal(xpm.RGB(0xFF, 0, 0)))
+		Expect(pads.PadColor(9)).To(Equal(xpm.RGB(0, 0, 0xFF)))
+		Expect(pads.PadColor(16)).To(BeZero())
+	})
+
+	It("should give new programs universal pads", func() {
+		for _, program := range []*xpm.MPCVObject{xpm.NewXPMKeygroup(), xpm.NewXPMDrum()} {
+			pads, err := program.Program.ProgramPads.Pads()
+			Expect(err).To(BeNil())
+			Expect(pads).To(Equal(xpm.NewPads()))
+			Expect(pads.PadColor(42)).To(Equal(xpm.DefaultPadColor))
+		}
+	})
+
+	It("should switch to per pad colours when a pad colour is set", func() {
+		pads := xpm.NewPads()
+		Expect(pads.SetPadColor(3, xpm.RGB(0xFF, 0x80, 0))).To(Succeed())
+		Expect(pads.Universal).To(BeFalse())
+		Expect(pads.Type).To(Equal(xpm.PadColorsPerPad))
+		Expect(pads.PadColor(3).String()).To(Equal("#FF8000"))
+		Expect(pads.PadColor(4)).To(Equal(xpm.DefaultPadColor))
+		Expect(pads.SetPadColor(128, 0)).ToNot(Succeed())
+
+		var content xpm.ProgramPadsContent
+		content.SetPads(pads)
+		Expect(content.Content).To(ContainSubstring("&quot;ProgramPads&quot;: {"))
+		parsed, err := content.Pads()
+		Expect(err).To(BeNil())
+		Expect(parsed).To(Equal(pads))
+	})
+})
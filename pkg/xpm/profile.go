@@ -0,0 +1,160 @@
+package xpm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ============================================================================
+// Target Profiles
+// ============================================================================
+
+// Profile describes the MPC a program is written for. Units refuse programs
+// whose header names a newer MPC OS than they run, older firmware has fewer
+// layers per keygroup, and each firmware reads its own set of elements.
+type Profile struct {
+	Name         string   // value of convert --target, e.g. "mpc-one"
+	Description  string   // shown in the GUI
+	Version      Version  // header the program is written with
+	MaxLayers    int      // layers per keygroup
+	MaxKeygroups int      // keygroups per program
+	PadsElement  string   // ProgramPads, or ProgramPads-v2.10 from MPC OS 2.10 on
+	Omit         []string // elements the firmware does not read, left out on Save
+}
+
+// DefaultTarget is the profile used when none is selected, and the one
+// NewXPMKeygroup and NewXPMDrum create programs for.
+const DefaultTarget = "mpc-one"
+
+// Every unit on MPC OS 2 plays 4 layers per keygroup and 128 keygroups, so
+// the profiles share the limits.
+const (
+	maxLayers    = 4
+	maxKeygroups = 128
+)
+
+// padsElementV210 is the pads element of MPC OS 2.10 and later.
+const padsElementV210 = ProgramPads + "-v2.10"
+
+// omitV29 lists the elements MPC OS 2.9 does not read: the trigger mode,
+// the envelope curves and the pitch envelope came with MPC OS 2.10.
+var omitV29 = []string{
+	InstrumentTriggerMode,
+	InstrumentFilterAttackCurve, InstrumentFilterDecayCurve, InstrumentFilterReleaseCurve,
+	InstrumentVolumeAttackCurve, InstrumentVolumeDecayCurve, InstrumentVolumeReleaseCurve,
+	InstrumentPitchAttack, InstrumentPitchHold, InstrumentPitchDecay, InstrumentPitchSustain,
+	InstrumentPitchRelease, InstrumentPitchAttackCurve, InstrumentPitchDecayCurve,
+	InstrumentPitchReleaseCurve, InstrumentPitchEnvAmount,
+}
+
+// omitV210 lists the elements MPC OS 2.10 and later no longer read: the
+// trigger mode replaced OneShot, and SliceLoop the layer Loop.
+var omitV210 = []string{InstrumentOneShot, LayerLoop}
+
+// Profiles lists the supported targets, oldest MPC OS first. The headers are
+// the ones of programs exported by each version: standalone units write
+// Linux, the 2.14 header is the one of Akai's expansion kits. There is no
+// profile for Live II or Force yet, no program they exported to copy from.
+var Profiles = []Profile{
+	{
+		Name:         "mpc-2.9",
+		Description:  "MPC Live, X or One on MPC OS 2.9",
+		Version:      mpcVersion("2.9.1.2", "Linux"),
+		MaxLayers:    maxLayers,
+		MaxKeygroups: maxKeygroups,
+		PadsElement:  ProgramPads,
+		Omit:         omitV29,
+	},
+	{
+		Name:         "mpc-one",
+		Description:  "MPC One on MPC OS 2.11",
+		Version:      mpcVersion("2.11.3.5", "Linux"),
+		MaxLayers:    maxLayers,
+		MaxKeygroups: maxKeygroups,
+		PadsElement:  padsElementV210,
+		Omit:         omitV210,
+	},
+	{
+		Name:         "mpc-2.14",
+		Description:  "MPC OS 2.14, as Akai's expansion kits",
+		Version:      mpcVersion("2.14.0.20", "Windows"),
+		MaxLayers:    maxLayers,
+		MaxKeygroups: maxKeygroups,
+		PadsElement:  padsElementV210,
+		Omit:         omitV210,
+	},
+}
+
+// mpcVersion returns the header MPC OS version writes on platform.
+func mpcVersion(version, platform string) Version {
+	return Version{
+		FileVersion:        "2.1",
+		Application:        "MPC-V",
+		ApplicationVersion: version,
+		Platform:           platform,
+	}
+}
+
+// LookupProfile returns the profile called name.
+func LookupProfile(name string) (*Profile, error) {
+	for i := range Profiles {
+		if strings.EqualFold(Profiles[i].Name, name) {
+			return &Profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown target %q, want one of %s", name, strings.Join(ProfileNames(), ", "))
+}
+
+// DefaultProfile returns the DefaultTarget profile, the second of Profiles.
+func DefaultProfile() *Profile {
+	return &Profiles[1]
+}
+
+// ProfileNames returns the names of Profiles in order.
+func ProfileNames() []string {
+	names := make([]string, len(Profiles))
+	for i, p := range Profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// SetProfile writes the program for p: it takes the header of p, moves the
+// pads to the element p's firmware reads and leaves out the elements it does
+// not read on Save.
+func (xpm *MPCVObject) SetProfile(p *Profile) error {
+	xpm.Version = p.Version
+	xpm.profile = p
+	pads := &xpm.Program.ProgramPads
+	name := pads.XMLName.Local
+	if name == "" {
+		name = ProgramPads
+	}
+	if name == p.PadsElement {
+		return nil
+	}
+	state, err := pads.Pads()
+	if err != nil {
+		return err
+	}
+	pads.XMLName = xml.Name{Local: p.PadsElement}
+	pads.SetPads(state)
+	return nil
+}
+
+// omitElements removes the elements called names from out, an indented
+// program. They are all leaves, written on a line of their own.
+func omitElements(out []byte, names []string) []byte {
+	if len(names) == 0 {
+		return out
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	elements := strings.Join(quoted, "|")
+	leaf := regexp.MustCompile(`(?m)^[ \t]*<(?:` + elements + `)>[^<]*</(?:` + elements + `)>\n`)
+	return leaf.ReplaceAll(out, nil)
+}
@@ -0,0 +1,75 @@
+package xpm_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+var _ = Describe("Profile", func() {
+	It("should look up targets by name", func() {
+		for _, name := range xpm.ProfileNames() {
+			p, err := xpm.LookupProfile(name)
+			Expect(err).To(BeNil())
+			Expect(p.Name).To(Equal(name))
+			Expect(p.MaxLayers).To(BeNumerically(">", 0))
+			Expect(p.MaxKeygroups).To(BeNumerically(">", 0))
+		}
+		_, err := xpm.LookupProfile("mpc-3000")
+		Expect(err).To(MatchError(ContainSubstring("mpc-one")))
+	})
+
+	It("should create new programs for the default target", func() {
+		p := xpm.DefaultProfile()
+		Expect(p.Name).To(Equal(xpm.DefaultTarget))
+		for _, program := range []*xpm.MPCVObject{xpm.NewXPMKeygroup(), xpm.NewXPMDrum()} {
+			Expect(program.Version).To(Equal(p.Version))
+			Expect(program.Program.ProgramPads.XMLName.Local).To(Equal(p.PadsElement))
+		}
+	})
+
+	It("should write older firmware headers and pads", func() {
+		p, err := xpm.LookupProfile("mpc-2.9")
+		Expect(err).To(BeNil())
+		program := xpm.NewXPMDrum()
+		pads, err := program.Program.ProgramPads.Pads()
+		Expect(err).To(BeNil())
+		Expect(pads.SetPadColor(0, xpm.RGB(0xFF, 0, 0))).To(Succeed())
+		program.Program.ProgramPads.SetPads(pads)
+
+		Expect(program.SetProfile(p)).To(Succeed())
+		Expect(program.Version.ApplicationVersion).To(Equal("2.9.1.2"))
+		Expect(program.Program.ProgramPads.XMLName.Local).To(Equal(xpm.ProgramPads))
+		Expect(program.Program.ProgramPads.Content).To(ContainSubstring("&quot;ProgramPads&quot;: {"))
+		moved, err := program.Program.ProgramPads.Pads()
+		Expect(err).To(BeNil())
+		Expect(moved).To(Equal(pads))
+	})
+
+	It("should leave out the elements the target does not read", func() {
+		dir := GinkgoT().TempDir()
+		for name, elements := range map[string][2]string{
+			"mpc-2.9": {"<OneShot>", "<TriggerMode>"},
+			"mpc-one": {"<TriggerMode>", "<OneShot>"},
+		} {
+			p, err := xpm.LookupProfile(name)
+			Expect(err).To(BeNil())
+			program := xpm.NewXPMKeygroup()
+			Expect(program.SetProfile(p)).To(Succeed())
+			file := filepath.Join(dir, name+".xpm")
+			Expect(program.Save(file)).To(Succeed())
+
+			data, err := os.ReadFile(file)
+			Expect(err).To(BeNil())
+			Expect(string(data)).To(ContainSubstring(elements[0]), name)
+			Expect(string(data)).ToNot(ContainSubstring(elements[1]), name)
+			saved, err := xpm.Load(file)
+			Expect(err).To(BeNil())
+			Expect(saved.Program.Instruments.Instrument).To(HaveLen(len(program.Program.Instruments.Instrument)))
+		}
+	})
+})
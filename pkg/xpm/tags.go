@@ -82,6 +82,7 @@ const (
 	LayerVelEnd             = "VelEnd"
 	LayerSampleStart        = "SampleStart"
 	LayerSampleEnd          = "SampleEnd"
+	LayerLoop               = "Loop"
 	LayerLoopStart          = "LoopStart"
 	LayerLoopEnd            = "LoopEnd"
 	LayerLoopCrossfade      = "LoopCrossfadeLength"
@@ -119,3 +120,37 @@ const (
 	True         = "True"
 	False        = "False"
 )
+
+// Filter types, the values of FilterType, in the order of the MPC filter
+// menu. The number is the poles of the filter, Classic is the low pass of
+// the older MPCs.
+const (
+	FilterOff = iota
+	FilterClassic
+	FilterLow4
+	FilterLow2
+	FilterLow1
+	FilterHigh4
+	FilterHigh2
+	FilterHigh1
+	FilterBand4
+	FilterBand2
+)
+
+// LFO waveforms, the values of LFO Type.
+const (
+	LfoSine     = "Sine"
+	LfoTriangle = "Triangle"
+	LfoSquare   = "Square"
+	LfoSawUp    = "Saw Up"
+	LfoSawDown  = "Saw Down"
+	LfoRandom   = "S&H"
+	LfoNoise    = "Noise"
+)
+
+// LfoSyncBeats lists the note values of the LFO Sync menu in quarter notes,
+// from 1/32 to 8 bars with the triplets. Sync n plays LfoSyncBeats[n-1], 0
+// runs the LFO free at its Rate.
+var LfoSyncBeats = []float64{
+	1.0 / 8, 1.0 / 6, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 1, 4.0 / 3, 2, 4, 8, 16, 32,
+}
@@ -9,6 +9,7 @@ type MPCVObject struct {
 	Text    string   `xml:",chardata"`
 	Version Version  `xml:"Version"`
 	Program Program  `xml:"Program"`
+	profile *Profile // set by SetProfile, nil for loaded programs
 }
 
 type Version struct {
@@ -24,7 +25,7 @@ type AudioRoute struct {
 	AudioRoute              int    `xml:"AudioRoute"`
 	AudioRouteSubIndex      int    `xml:"AudioRouteSubIndex"`
 	AudioRouteChannelBitmap int    `xml:"AudioRouteChannelBitmap"`
-	InsertsEnabled          string `xml:"InsertsEnabled"`
+	InsertsEnabled          Bool   `xml:"InsertsEnabled"`
 }
 
 type Instruments struct {
@@ -33,21 +34,21 @@ type Instruments struct {
 }
 
 type LFO struct {
-	Text         string `xml:",chardata"`
-	Type         string `xml:"Type"`
-	Rate         string `xml:"Rate"`
-	Sync         int    `xml:"Sync"`
-	Reset        string `xml:"Reset"`
-	PitchAmount  string `xml:"PitchAmount"`
-	CutoffAmount string `xml:"CutoffAmount"`
-	VolumeAmount string `xml:"VolumeAmount"`
-	PanAmount    string `xml:"PanAmount"`
-	Delay        string `xml:"Delay"`
-	FadeIn       string `xml:"FadeIn"`
-	Attack       string `xml:"Attack"`
-	Depth        string `xml:"Depth"`
-	Phase        string `xml:"Phase"`
-	Offset       string `xml:"Offset"`
+	Text         string     `xml:",chardata"`
+	Type         string     `xml:"Type"`
+	Rate         Normalized `xml:"Rate"`
+	Sync         int        `xml:"Sync"`
+	Reset        Bool       `xml:"Reset"`
+	PitchAmount  Normalized `xml:"PitchAmount"`
+	CutoffAmount Normalized `xml:"CutoffAmount"`
+	VolumeAmount Normalized `xml:"VolumeAmount"`
+	PanAmount    Normalized `xml:"PanAmount"`
+	Delay        Normalized `xml:"Delay"`
+	FadeIn       Normalized `xml:"FadeIn"`
+	Attack       Normalized `xml:"Attack"`
+	Depth        Normalized `xml:"Depth"`
+	Phase        Normalized `xml:"Phase"`
+	Offset       Normalized `xml:"Offset"`
 }
 
 type Layers struct {
@@ -56,60 +57,60 @@ type Layers struct {
 }
 
 type Layer struct {
-	Text                     string `xml:",chardata"`
-	Number                   string `xml:"number,attr"`
-	Active                   string `xml:"Active"`
-	Volume                   string `xml:"Volume"`
-	Pan                      string `xml:"Pan"`
-	Pitch                    string `xml:"Pitch"`
-	TuneCoarse               int    `xml:"TuneCoarse"`
-	TuneFine                 int    `xml:"TuneFine"`
-	VelStart                 int    `xml:"VelStart"`
-	VelEnd                   int    `xml:"VelEnd"`
-	SampleStart              int    `xml:"SampleStart"`
-	SampleEnd                int    `xml:"SampleEnd"`
-	Loop                     string `xml:"Loop"`
-	LoopStart                int    `xml:"LoopStart"`
-	LoopEnd                  int    `xml:"LoopEnd"`
-	LoopCrossfadeLength      int    `xml:"LoopCrossfadeLength"`
-	LoopTune                 int    `xml:"LoopTune"`
-	Mute                     string `xml:"Mute"`
-	RootNote                 int    `xml:"RootNote"`
-	KeyTrack                 string `xml:"KeyTrack"`
-	SampleName               string `xml:"SampleName"`
-	SampleFile               string `xml:"SampleFile"`
-	SliceIndex               int    `xml:"SliceIndex"`
-	Direction                int    `xml:"Direction"`
-	Offset                   int    `xml:"Offset"`
-	SliceStart               int    `xml:"SliceStart"`
-	SliceEnd                 int    `xml:"SliceEnd"`
-	SliceLoopStart           int    `xml:"SliceLoopStart"`
-	SliceLoop                int    `xml:"SliceLoop"`
-	SliceLoopCrossFadeLength int    `xml:"SliceLoopCrossFadeLength"`
+	Text                     string     `xml:",chardata"`
+	Number                   string     `xml:"number,attr"`
+	Active                   Bool       `xml:"Active"`
+	Volume                   Gain       `xml:"Volume"`
+	Pan                      Normalized `xml:"Pan"`
+	Pitch                    Float      `xml:"Pitch"`
+	TuneCoarse               int        `xml:"TuneCoarse"`
+	TuneFine                 int        `xml:"TuneFine"`
+	VelStart                 int        `xml:"VelStart"`
+	VelEnd                   int        `xml:"VelEnd"`
+	SampleStart              int        `xml:"SampleStart"`
+	SampleEnd                int        `xml:"SampleEnd"`
+	Loop                     Bool       `xml:"Loop"`
+	LoopStart                int        `xml:"LoopStart"`
+	LoopEnd                  int        `xml:"LoopEnd"`
+	LoopCrossfadeLength      int        `xml:"LoopCrossfadeLength"`
+	LoopTune                 int        `xml:"LoopTune"`
+	Mute                     Bool       `xml:"Mute"`
+	RootNote                 int        `xml:"RootNote"`
+	KeyTrack                 Bool       `xml:"KeyTrack"`
+	SampleName               string     `xml:"SampleName"`
+	SampleFile               string     `xml:"SampleFile"`
+	SliceIndex               int        `xml:"SliceIndex"`
+	Direction                int        `xml:"Direction"`
+	Offset                   int        `xml:"Offset"`
+	SliceStart               int        `xml:"SliceStart"`
+	SliceEnd                 int        `xml:"SliceEnd"`
+	SliceLoopStart           int        `xml:"SliceLoopStart"`
+	SliceLoop                int        `xml:"SliceLoop"`
+	SliceLoopCrossFadeLength int        `xml:"SliceLoopCrossFadeLength"`
 }
 
 type Instrument struct {
 	Text                     string     `xml:",chardata"`
 	Number                   string     `xml:"number,attr"`
-	CueBusEnable             string     `xml:"CueBusEnable"`
+	CueBusEnable             Bool       `xml:"CueBusEnable"`
 	AudioRoute               AudioRoute `xml:"AudioRoute"`
-	Send1                    string     `xml:"Send1"`
-	Send2                    string     `xml:"Send2"`
-	Send3                    string     `xml:"Send3"`
-	Send4                    string     `xml:"Send4"`
-	Volume                   string     `xml:"Volume"`
-	Mute                     string     `xml:"Mute"`
-	Solo                     string     `xml:"Solo"`
-	Pan                      string     `xml:"Pan"`
+	Send1                    Normalized `xml:"Send1"`
+	Send2                    Normalized `xml:"Send2"`
+	Send3                    Normalized `xml:"Send3"`
+	Send4                    Normalized `xml:"Send4"`
+	Volume                   Gain       `xml:"Volume"`
+	Mute                     Bool       `xml:"Mute"`
+	Solo                     Bool       `xml:"Solo"`
+	Pan                      Normalized `xml:"Pan"`
 	AutomationFilter         int        `xml:"AutomationFilter"`
 	TuneCoarse               int        `xml:"TuneCoarse"`
 	TuneFine                 int        `xml:"TuneFine"`
-	Mono                     string     `xml:"Mono"`
+	Mono                     Bool       `xml:"Mono"`
 	Polyphony                int        `xml:"Polyphony"`
-	FilterKeytrack           string     `xml:"FilterKeytrack"`
+	FilterKeytrack           Normalized `xml:"FilterKeytrack"`
 	LowNote                  int        `xml:"LowNote"`
 	HighNote                 int        `xml:"HighNote"`
-	IgnoreBaseNote           string     `xml:"IgnoreBaseNote"`
+	IgnoreBaseNote           Bool       `xml:"IgnoreBaseNote"`
 	ZonePlay                 int        `xml:"ZonePlay"` // 0=CYCLE (round robin), 1=VELOCITY, 2=RANDOM
 	MuteGroup                int        `xml:"MuteGroup"`
 	MuteTarget1              int        `xml:"MuteTarget1"`
@@ -120,63 +121,66 @@ type Instrument struct {
 	SimultTarget2            int        `xml:"SimultTarget2"`
 	SimultTarget3            int        `xml:"SimultTarget3"`
 	SimultTarget4            int        `xml:"SimultTarget4"`
-	LfoPitch                 string     `xml:"LfoPitch"`
-	LfoCutoff                string     `xml:"LfoCutoff"`
-	LfoVolume                string     `xml:"LfoVolume"`
-	LfoPan                   string     `xml:"LfoPan"`
-	OneShot                  string     `xml:"OneShot"`
+	LfoPitch                 Normalized `xml:"LfoPitch"`
+	LfoCutoff                Normalized `xml:"LfoCutoff"`
+	LfoVolume                Normalized `xml:"LfoVolume"`
+	LfoPan                   Normalized `xml:"LfoPan"`
+	OneShot                  Bool       `xml:"OneShot"`
 	FilterType               int        `xml:"FilterType"`
-	Cutoff                   string     `xml:"Cutoff"`
-	Resonance                string     `xml:"Resonance"`
-	FilterEnvAmt             string     `xml:"FilterEnvAmt"`
-	AfterTouchToFilter       string     `xml:"AfterTouchToFilter"`
-	VelocityToStart          string     `xml:"VelocityToStart"`
-	VelocityToFilterAttack   string     `xml:"VelocityToFilterAttack"`
-	VelocityToFilter         string     `xml:"VelocityToFilter"`
-	VelocityToFilterEnvelope string     `xml:"VelocityToFilterEnvelope"`
-	FilterAttack             string     `xml:"FilterAttack"`
-	FilterDecay              string     `xml:"FilterDecay"`
-	FilterSustain            string     `xml:"FilterSustain"`
-	FilterRelease            string     `xml:"FilterRelease"`
-	FilterHold               string     `xml:"FilterHold"`
-	FilterDecayType          string     `xml:"FilterDecayType"`
-	FilterADEnvelope         string     `xml:"FilterADEnvelope"`
-	FilterAttackCurve        string     `xml:"FilterAttackCurve"`
-	FilterDecayCurve         string     `xml:"FilterDecayCurve"`
-	FilterReleaseCurve       string     `xml:"FilterReleaseCurve"`
-	VolumeHold               string     `xml:"VolumeHold"`
-	VolumeDecayType          string     `xml:"VolumeDecayType"`
-	VolumeADEnvelope         string     `xml:"VolumeADEnvelope"`
-	VolumeAttack             string     `xml:"VolumeAttack"`
-	VolumeDecay              string     `xml:"VolumeDecay"`
-	VolumeSustain            string     `xml:"VolumeSustain"`
-	VolumeRelease            string     `xml:"VolumeRelease"`
-	VolumeAttackCurve        string     `xml:"VolumeAttackCurve"`
-	VolumeDecayCurve         string     `xml:"VolumeDecayCurve"`
-	VolumeReleaseCurve       string     `xml:"VolumeReleaseCurve"`
-	PitchAttack              string     `xml:"PitchAttack"`
-	PitchHold                string     `xml:"PitchHold"`
-	PitchDecay               string     `xml:"PitchDecay"`
-	PitchSustain             string     `xml:"PitchSustain"`
-	PitchRelease             string     `xml:"PitchRelease"`
-	PitchAttackCurve         string     `xml:"PitchAttackCurve"`
-	PitchDecayCurve          string     `xml:"PitchDecayCurve"`
-	PitchReleaseCurve        string     `xml:"PitchReleaseCurve"`
-	PitchEnvAmount           string     `xml:"PitchEnvAmount"`
-	VelocityToPitch          string     `xml:"VelocityToPitch"`
-	VelocityToVolumeAttack   string     `xml:"VelocityToVolumeAttack"`
-	VelocitySensitivity      string     `xml:"VelocitySensitivity"`
-	VelocityToPan            string     `xml:"VelocityToPan"`
+	Cutoff                   Normalized `xml:"Cutoff"`
+	Resonance                Normalized `xml:"Resonance"`
+	FilterEnvAmt             Normalized `xml:"FilterEnvAmt"`
+	AfterTouchToFilter       Normalized `xml:"AfterTouchToFilter"`
+	VelocityToStart          Normalized `xml:"VelocityToStart"`
+	VelocityToFilterAttack   Normalized `xml:"VelocityToFilterAttack"`
+	VelocityToFilter         Normalized `xml:"VelocityToFilter"`
+	VelocityToFilterEnvelope Normalized `xml:"VelocityToFilterEnvelope"`
+	FilterAttack             Normalized `xml:"FilterAttack"`
+	FilterDecay              Normalized `xml:"FilterDecay"`
+	FilterSustain            Normalized `xml:"FilterSustain"`
+	FilterRelease            Normalized `xml:"FilterRelease"`
+	FilterHold               Normalized `xml:"FilterHold"`
+	FilterDecayType          Bool       `xml:"FilterDecayType"`
+	FilterADEnvelope         Bool       `xml:"FilterADEnvelope"`
+	FilterAttackCurve        Normalized `xml:"FilterAttackCurve"`
+	FilterDecayCurve         Normalized `xml:"FilterDecayCurve"`
+	FilterReleaseCurve       Normalized `xml:"FilterReleaseCurve"`
+	VolumeHold               Normalized `xml:"VolumeHold"`
+	VolumeDecayType          Bool       `xml:"VolumeDecayType"`
+	VolumeADEnvelope         Bool       `xml:"VolumeADEnvelope"`
+	VolumeAttack             Normalized `xml:"VolumeAttack"`
+	VolumeDecay              Normalized `xml:"VolumeDecay"`
+	VolumeSustain            Normalized `xml:"VolumeSustain"`
+	VolumeRelease            Normalized `xml:"VolumeRelease"`
+	VolumeAttackCurve        Normalized `xml:"VolumeAttackCurve"`
+	VolumeDecayCurve         Normalized `xml:"VolumeDecayCurve"`
+	VolumeReleaseCurve       Normalized `xml:"VolumeReleaseCurve"`
+	PitchAttack              Normalized `xml:"PitchAttack"`
+	PitchHold                Normalized `xml:"PitchHold"`
+	PitchDecay               Normalized `xml:"PitchDecay"`
+	PitchSustain             Normalized `xml:"PitchSustain"`
+	PitchRelease             Normalized `xml:"PitchRelease"`
+	PitchAttackCurve         Normalized `xml:"PitchAttackCurve"`
+	PitchDecayCurve          Normalized `xml:"PitchDecayCurve"`
+	PitchReleaseCurve        Normalized `xml:"PitchReleaseCurve"`
+	PitchEnvAmount           Normalized `xml:"PitchEnvAmount"`
+	VelocityToPitch          Normalized `xml:"VelocityToPitch"`
+	VelocityToVolumeAttack   Normalized `xml:"VelocityToVolumeAttack"`
+	VelocitySensitivity      Normalized `xml:"VelocitySensitivity"`
+	VelocityToPan            Normalized `xml:"VelocityToPan"`
 	TriggerMode              int        `xml:"TriggerMode"`
 	LFO                      LFO        `xml:"LFO"`
-	WarpTempo                string     `xml:"WarpTempo"`
-	BpmLock                  string     `xml:"BpmLock"`
-	WarpEnable               string     `xml:"WarpEnable"`
+	WarpTempo                Float      `xml:"WarpTempo"`
+	BpmLock                  Bool       `xml:"BpmLock"`
+	WarpEnable               Bool       `xml:"WarpEnable"`
 	StretchPercentage        int        `xml:"StretchPercentage"`
 	Layers                   Layers     `xml:"Layers"`
 }
 
+// ProgramPadsContent holds the escaped JSON of the pads element as is.
+// XMLName is the element name it was read with, empty for ProgramPads.
 type ProgramPadsContent struct {
+	XMLName xml.Name
 	Content string `xml:",innerxml"`
 }
 
@@ -186,35 +190,35 @@ type Program struct {
 	ProgramName string             `xml:"ProgramName"`
 	ProgramPads ProgramPadsContent `xml:"ProgramPads"`
 	//ProgramPadsContent string     `xml:",innerxml"`
-	CueBusEnable string     `xml:"CueBusEnable"`
+	CueBusEnable Bool       `xml:"CueBusEnable"`
 	AudioRoute   AudioRoute `xml:"AudioRoute"`
 
-	Send1                      string       `xml:"Send1"`
-	Send2                      string       `xml:"Send2"`
-	Send3                      string       `xml:"Send3"`
-	Send4                      string       `xml:"Send4"`
-	Volume                     string       `xml:"Volume"`
-	Mute                       string       `xml:"Mute"`
-	Solo                       string       `xml:"Solo"`
-	Pan                        string       `xml:"Pan"`
+	Send1                      Normalized   `xml:"Send1"`
+	Send2                      Normalized   `xml:"Send2"`
+	Send3                      Normalized   `xml:"Send3"`
+	Send4                      Normalized   `xml:"Send4"`
+	Volume                     Gain         `xml:"Volume"`
+	Mute                       Bool         `xml:"Mute"`
+	Solo                       Bool         `xml:"Solo"`
+	Pan                        Normalized   `xml:"Pan"`
 	AutomationFilter           int          `xml:"AutomationFilter"`
-	Pitch                      string       `xml:"Pitch"`
+	Pitch                      Float        `xml:"Pitch"`
 	TuneCoarse                 int          `xml:"TuneCoarse"`
 	TuneFine                   int          `xml:"TuneFine"`
-	Mono                       string       `xml:"Mono"`
+	Mono                       Bool         `xml:"Mono"`
 	ProgramPolyphony           int          `xml:"Program_Polyphony"`
-	PortamentoTime             string       `xml:"PortamentoTime"`
-	PortamentoLegato           string       `xml:"PortamentoLegato"`
-	PortamentoQuantized        string       `xml:"PortamentoQuantized"`
+	PortamentoTime             Normalized   `xml:"PortamentoTime"`
+	PortamentoLegato           Bool         `xml:"PortamentoLegato"`
+	PortamentoQuantized        Bool         `xml:"PortamentoQuantized"`
 	ProgramXfaderRoute         int          `xml:"Program.Xfader.Route"`
 	Instruments                Instruments  `xml:"Instruments"`
 	PadNoteMap                 *PadNoteMap  `xml:"PadNoteMap,omitempty"`
 	PadGroupMap                *PadGroupMap `xml:"PadGroupMap,omitempty"`
-	KeygroupMasterTranspose    string       `xml:"KeygroupMasterTranspose"`
+	KeygroupMasterTranspose    Normalized   `xml:"KeygroupMasterTranspose"`
 	KeygroupNumKeygroups       int          `xml:"KeygroupNumKeygroups"`
-	KeygroupPitchBendRange     string       `xml:"KeygroupPitchBendRange"`
-	KeygroupWheelToLfo         string       `xml:"KeygroupWheelToLfo"`
-	KeygroupAftertouchToFilter string       `xml:"KeygroupAftertouchToFilter"`
+	KeygroupPitchBendRange     Normalized   `xml:"KeygroupPitchBendRange"`
+	KeygroupWheelToLfo         Normalized   `xml:"KeygroupWheelToLfo"`
+	KeygroupAftertouchToFilter Normalized   `xml:"KeygroupAftertouchToFilter"`
 	QLinkAssignments           string       `xml:"QLinkAssignments"`
 }
 
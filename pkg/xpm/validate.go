@@ -0,0 +1,146 @@
+package xpm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// Validation
+// ============================================================================
+
+// MaxSampleNameLength is the longest sample name the MPC browser shows in
+// full.
+const MaxSampleNameLength = 32
+
+// Severity tells how serious a Finding is.
+type Severity int
+
+const (
+	// SeverityWarning marks a program that loads but likely does not play
+	// as meant.
+	SeverityWarning Severity = iota
+	// SeverityError marks a program the MPC refuses or plays wrongly.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Finding is a problem reported by Validate.
+type Finding struct {
+	Severity   Severity
+	Instrument int // zero based instrument index, -1 if the finding is not about one
+	Layer      int // zero based layer index, -1 if the finding is not about one
+	Message    string
+}
+
+func (f Finding) String() string {
+	switch {
+	case f.Layer >= 0:
+		return fmt.Sprintf("%s: instrument %d: layer %d: %s", f.Severity, f.Instrument, f.Layer, f.Message)
+	case f.Instrument >= 0:
+		return fmt.Sprintf("%s: instrument %d: %s", f.Severity, f.Instrument, f.Message)
+	default:
+		return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+	}
+}
+
+// HasErrors reports whether findings holds a finding of SeverityError.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the program against the limits of target, the default
+// target if nil: the number of keygroups and of layers per instrument,
+// inverted note and velocity ranges, keygroups that overlap, sample names
+// too long for the browser and, unless dir is empty, layers whose
+// SampleFile is not in dir, the directory the program is saved to. It
+// returns nil if there are no problems.
+func (xpm *MPCVObject) Validate(dir string, target *Profile) []Finding {
+	if target == nil {
+		target = DefaultProfile()
+	}
+	var findings []Finding
+	add := func(severity Severity, instrument, layer int, format string, args ...interface{}) {
+		findings = append(findings, Finding{Severity: severity, Instrument: instrument, Layer: layer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var files map[string]bool
+	if dir != "" {
+		var err error
+		if files, err = listFiles(dir); err != nil {
+			add(SeverityError, -1, -1, "cannot read program directory: %v", err)
+		}
+	}
+
+	instruments := xpm.Program.Instruments.Instrument
+	if xpm.Program.IsKeygroup() && len(instruments) > target.MaxKeygroups {
+		add(SeverityError, -1, -1, "%d keygroups, %s plays %d", len(instruments), target.Description, target.MaxKeygroups)
+	}
+
+	for i, instrument := range instruments {
+		if instrument.LowNote > instrument.HighNote {
+			add(SeverityError, i, -1, "inverted note range %d-%d", instrument.LowNote, instrument.HighNote)
+		}
+		layers := instrument.Layers.Layer
+		if len(layers) > target.MaxLayers {
+			add(SeverityError, i, -1, "%d layers, %s plays %d", len(layers), target.Description, target.MaxLayers)
+		}
+		for j, layer := range layers {
+			if layer.VelStart > layer.VelEnd {
+				add(SeverityError, i, j, "inverted velocity range %d-%d", layer.VelStart, layer.VelEnd)
+			}
+			if n := utf8.RuneCountInString(layer.SampleName); n > MaxSampleNameLength {
+				add(SeverityWarning, i, j, "sample name %q is %d characters, the browser shows %d", layer.SampleName, n, MaxSampleNameLength)
+			}
+			if files != nil && layer.SampleFile != "" && !files[strings.ToLower(filepath.Base(layer.SampleFile))] {
+				add(SeverityError, i, j, "sample file %q is not in %s", layer.SampleFile, dir)
+			}
+		}
+	}
+
+	if xpm.Program.IsKeygroup() {
+		for i, a := range instruments {
+			for j := i + 1; j < len(instruments); j++ {
+				b := instruments[j]
+				if a.LowNote <= b.HighNote && b.LowNote <= a.HighNote {
+					add(SeverityWarning, j, -1, "notes %d-%d overlap instrument %d (%d-%d)", b.LowNote, b.HighNote, i, a.LowNote, a.HighNote)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// listFiles returns the lower case names of the files in dir. The MPC
+// stores programs on FAT and exFAT cards, which ignore case.
+func listFiles(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files[strings.ToLower(entry.Name())] = true
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,113 @@
+package xpm_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+var _ = Describe("Validate", func() {
+	// valid returns a keygroup program with two keygroups and their samples
+	// in dir, without findings.
+	valid := func(dir string) *xpm.MPCVObject {
+		program := xpm.NewXPMKeygroup()
+		program.Program.Instruments.Instrument = program.Program.Instruments.Instrument[:2]
+		for i, note := range []int{0, 60} {
+			instrument := &program.Program.Instruments.Instrument[i]
+			instrument.LowNote, instrument.HighNote = note, note+59
+			instrument.Layers.Layer = instrument.Layers.Layer[:1]
+			layer := &instrument.Layers.Layer[0]
+			layer.SampleName = "C" + string(rune('3'+i))
+			layer.SampleFile = layer.SampleName + ".WAV"
+			Expect(os.WriteFile(filepath.Join(dir, layer.SampleFile), nil, 0644)).To(Succeed())
+		}
+		return program
+	}
+
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should accept a valid program", func() {
+		Expect(valid(dir).Validate(dir, nil)).To(BeEmpty())
+	})
+
+	It("should flag inverted ranges", func() {
+		program := valid(dir)
+		program.Program.Instruments.Instrument[0].LowNote = 70
+		program.Program.Instruments.Instrument[1].Layers.Layer[0].VelStart = 100
+		program.Program.Instruments.Instrument[1].Layers.Layer[0].VelEnd = 10
+		findings := program.Validate(dir, nil)
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].String()).To(Equal("error: instrument 0: inverted note range 70-59"))
+		Expect(findings[1].String()).To(Equal("error: instrument 1: layer 0: inverted velocity range 100-10"))
+		Expect(xpm.HasErrors(findings)).To(BeTrue())
+	})
+
+	It("should flag more layers and keygroups than the target plays", func() {
+		target, err := xpm.LookupProfile("mpc-2.9")
+		Expect(err).To(BeNil())
+		program := valid(dir)
+		instrument := &program.Program.Instruments.Instrument[1]
+		for len(instrument.Layers.Layer) <= target.MaxLayers {
+			instrument.Layers.Layer = append(instrument.Layers.Layer, xpm.Layer{VelEnd: 127})
+		}
+		findings := program.Validate(dir, target)
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Instrument).To(Equal(1))
+		Expect(findings[0].Layer).To(Equal(-1))
+
+		program = valid(dir)
+		for len(program.Program.Instruments.Instrument) <= target.MaxKeygroups {
+			program.Program.Instruments.Instrument = append(program.Program.Instruments.Instrument, xpm.Instrument{LowNote: 127, HighNote: 127})
+		}
+		findings = program.Validate("", target)
+		Expect(findings[0].String()).To(HavePrefix("error: 129 keygroups"))
+	})
+
+	It("should flag missing samples regardless of case", func() {
+		program := valid(dir)
+		program.Program.Instruments.Instrument[0].Layers.Layer[0].SampleFile = "c3.wav"
+		Expect(program.Validate(dir, nil)).To(BeEmpty())
+
+		Expect(os.Remove(filepath.Join(dir, "C4.WAV"))).To(Succeed())
+		findings := program.Validate(dir, nil)
+		Expect(findings).To(HaveLen(1))
+		Expect(findings[0].Severity).To(Equal(xpm.SeverityError))
+		Expect(findings[0].Message).To(ContainSubstring(`"C4.WAV"`))
+		Expect(program.Validate("", nil)).To(BeEmpty())
+	})
+
+	It("should warn about overlapping keygroups and long sample names", func() {
+		program := valid(dir)
+		program.Program.Instruments.Instrument[1].LowNote = 50
+		program.Program.Instruments.Instrument[0].Layers.Layer[0].SampleName = strings.Repeat("x", xpm.MaxSampleNameLength+1)
+		findings := program.Validate(dir, nil)
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Severity).To(Equal(xpm.SeverityWarning))
+		Expect(findings[1].String()).To(Equal("warning: instrument 1: notes 50-119 overlap instrument 0 (0-59)"))
+		Expect(xpm.HasErrors(findings)).To(BeFalse())
+	})
+
+	It("should not check drum pads for overlaps", func() {
+		program := valid(dir)
+		program.Program.Type = xpm.TypeDrum
+		program.Program.Instruments.Instrument[1].LowNote = 0
+		Expect(program.Validate(dir, nil)).To(BeEmpty())
+	})
+
+	It("should accept the programs written by the MPC", func() {
+		files, _ := filepath.Glob("testdata/*.xpm")
+		for _, file := range files {
+			program, err := xpm.Load(file)
+			Expect(err).To(BeNil())
+			Expect(xpm.HasErrors(program.Validate(filepath.Dir(file), nil))).To(BeFalse(), file)
+		}
+	})
+})
@@ -0,0 +1,139 @@
+package xpm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Values
+// ============================================================================
+
+// The MPC writes numbers with six decimals and booleans as True or False.
+// The types below write themselves the same way, so programs hold real
+// values and only the XML encoding deals with their text.
+
+// Bool is an MPC boolean, written as True or False.
+type Bool bool
+
+func (b Bool) String() string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
+
+// MarshalText writes b as True or False.
+func (b Bool) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText reads True or False in any case, 1 or 0, or an empty
+// element as false.
+func (b *Bool) UnmarshalText(text []byte) error {
+	switch s := strings.TrimSpace(string(text)); {
+	case strings.EqualFold(s, "true"), s == "1":
+		*b = true
+	case strings.EqualFold(s, "false"), s == "0", s == "":
+		*b = false
+	default:
+		return fmt.Errorf("invalid MPC bool %q", s)
+	}
+	return nil
+}
+
+// Float is an MPC number without a fixed range, such as a tempo.
+type Float float64
+
+func (f Float) String() string {
+	return formatFloat(float64(f))
+}
+
+// MarshalText writes f with six decimals.
+func (f Float) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText reads a number, or an empty element as zero.
+func (f *Float) UnmarshalText(text []byte) error {
+	v, err := parseFloat(text)
+	*f = Float(v)
+	return err
+}
+
+// Normalized is an MPC parameter in [0, 1], the range of most knobs.
+// Bipolar parameters such as Pan and PitchEnvAmount are centred on 0.5.
+type Normalized float64
+
+// Clamp returns n limited to [0, 1].
+func (n Normalized) Clamp() Normalized {
+	return Normalized(math.Max(0, math.Min(1, float64(n))))
+}
+
+func (n Normalized) String() string {
+	return formatFloat(float64(n.Clamp()))
+}
+
+// MarshalText writes n with six decimals, clamped to [0, 1].
+func (n Normalized) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText reads a number, or an empty element as zero.
+func (n *Normalized) UnmarshalText(text []byte) error {
+	v, err := parseFloat(text)
+	*n = Normalized(v)
+	return err
+}
+
+// Gain is a linear gain, 1 being unity. The MPC stores volumes this way,
+// new programs at 0.707946, which is -3 dB.
+type Gain float64
+
+// GainFromDB returns the linear gain of db decibels.
+func GainFromDB(db float64) Gain {
+	return Gain(math.Pow(10, db/20))
+}
+
+// DB returns g in decibels, -Inf for silence.
+func (g Gain) DB() float64 {
+	return 20 * math.Log10(float64(g))
+}
+
+func (g Gain) String() string {
+	return formatFloat(float64(g))
+}
+
+// MarshalText writes g with six decimals.
+func (g Gain) MarshalText() ([]byte, error) {
+	return []byte(g.String()), nil
+}
+
+// UnmarshalText reads a number, or an empty element as zero.
+func (g *Gain) UnmarshalText(text []byte) error {
+	v, err := parseFloat(text)
+	*g = Gain(v)
+	return err
+}
+
+func formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 6, 64)
+	if s == "-0.000000" { // the MPC has no negative zero
+		return "0.000000"
+	}
+	return s
+}
+
+func parseFloat(text []byte) (float64, error) {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid MPC number %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,127 @@
+package xpm_test
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/cldmnky/exsconvert/pkg/xpm"
+)
+
+var _ = Describe("Values", func() {
+	It("should write booleans as the MPC does", func() {
+		out, err := xml.Marshal(struct {
+			XMLName xml.Name `xml:"Layer"`
+			Active  xpm.Bool `xml:"Active"`
+			Mute    xpm.Bool `xml:"Mute"`
+		}{Active: true})
+		Expect(err).To(BeNil())
+		Expect(string(out)).To(Equal("<Layer><Active>True</Active><Mute>False</Mute></Layer>"))
+	})
+
+	It("should read booleans in any case", func() {
+		for text, want := range map[string]xpm.Bool{"True": true, "true": true, "1": true, "False": false, "FALSE": false, "": false} {
+			var b xpm.Bool
+			Expect(b.UnmarshalText([]byte(text))).To(Succeed())
+			Expect(b).To(Equal(want), text)
+		}
+		var b xpm.Bool
+		Expect(b.UnmarshalText([]byte("Free"))).ToNot(Succeed())
+	})
+
+	It("should write numbers with six decimals", func() {
+		Expect(xpm.Float(129.569).String()).To(Equal("129.569000"))
+		Expect(xpm.Float(math.Copysign(0, -1)).String()).To(Equal("0.000000"))
+		Expect(xpm.Normalized(2.0 / 12).String()).To(Equal("0.166667"))
+		Expect(xpm.Gain(1).String()).To(Equal("1.000000"))
+	})
+
+	It("should clamp normalized values when writing them", func() {
+		Expect(xpm.Normalized(1.5).String()).To(Equal("1.000000"))
+		Expect(xpm.Normalized(-0.2).String()).To(Equal("0.000000"))
+		Expect(xpm.Normalized(0.25).Clamp()).To(Equal(xpm.Normalized(0.25)))
+	})
+
+	It("should read empty numbers as zero and reject text", func() {
+		var n xpm.Normalized
+		Expect(n.UnmarshalText(nil)).To(Succeed())
+		Expect(n).To(BeZero())
+		Expect(n.UnmarshalText([]byte("0.5"))).To(Succeed())
+		Expect(n).To(Equal(xpm.Normalized(0.5)))
+		Expect(n.UnmarshalText([]byte("half"))).ToNot(Succeed())
+	})
+
+	It("should convert gains from and to decibels", func() {
+		Expect(xpm.GainFromDB(-3).String()).To(Equal("0.707946"))
+		Expect(xpm.GainFromDB(0)).To(Equal(xpm.Gain(1)))
+		Expect(xpm.GainFromDB(-6).DB()).To(BeNumerically("~", -6, 1e-9))
+		Expect(math.IsInf(xpm.Gain(0).DB(), -1)).To(BeTrue())
+	})
+
+	files, _ := filepath.Glob("testdata/*.xpm")
+	for _, file := range files {
+		file := file
+		It("should write back the values of "+filepath.Base(file)+" as they were", func() {
+			program, err := xpm.Load(file)
+			Expect(err).To(BeNil())
+			saved := filepath.Join(GinkgoT().TempDir(), filepath.Base(file))
+			Expect(program.Save(saved)).To(Succeed())
+
+			// Saving adds the elements the MPC leaves out and drops the ones
+			// Program does not model, but every other element the MPC
+			// wrote must come back with the same text.
+			written := leafValues(saved)
+			for path, values := range leafValues(file) {
+				if written[path] == nil {
+					continue
+				}
+				for text, n := range values {
+					Expect(written[path][text]).To(BeNumerically(">=", n), "%s: %q", path, text)
+				}
+			}
+		})
+	}
+})
+
+// leafValues counts the texts of the elements without children in file by
+// their path.
+func leafValues(file string) map[string]map[string]int {
+	f, err := os.Open(file)
+	Expect(err).To(BeNil())
+	defer f.Close()
+	values := make(map[string]map[string]int)
+	var path []string
+	var text string
+	leaf := false
+	d := xml.NewDecoder(f)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return values
+		}
+		Expect(err).To(BeNil())
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			text, leaf = "", true
+		case xml.CharData:
+			text = string(t)
+		case xml.EndElement:
+			if leaf {
+				key := strings.Join(path, "/")
+				if values[key] == nil {
+					values[key] = make(map[string]int)
+				}
+				values[key][text]++
+			}
+			path = path[:len(path)-1]
+			leaf = false
+		}
+	}
+}
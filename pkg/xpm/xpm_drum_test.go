@@ -53,7 +53,7 @@ var _ = Describe("Drum Program XPM Generation", func() {
 			drumXPM := xpm.NewXPMDrum()
 
 			// No pitch bend for drums
-			Expect(drumXPM.Program.KeygroupPitchBendRange).To(Equal("0.000000"))
+			Expect(drumXPM.Program.KeygroupPitchBendRange.String()).To(Equal("0.000000"))
 
 			// Each instrument should have polyphony of 1 (monophonic per pad)
 			for _, instrument := range drumXPM.Program.Instruments.Instrument {
@@ -81,6 +81,50 @@ var _ = Describe("Drum Program XPM Generation", func() {
 		})
 	})
 
+	Context("When looking up the pad of a note", func() {
+		It("should return the pad PadNoteMap assigns the note", func() {
+			padNoteMap := xpm.NewXPMDrum().Program.PadNoteMap
+
+			pad, ok := padNoteMap.Pad(36)
+			Expect(ok).To(BeTrue())
+			Expect(pad).To(Equal(36))
+
+			padNoteMap.PadNote[0].Note = 36
+			pad, ok = padNoteMap.Pad(36)
+			Expect(ok).To(BeTrue())
+			Expect(pad).To(Equal(0))
+		})
+
+		It("should give the pads the notes they are set to and the others the rest", func() {
+			padNoteMap := xpm.NewXPMDrum().Program.PadNoteMap
+			padNoteMap.SetNotes(map[int]int{0: 36, 1: 38, 3: 0})
+
+			Expect(padNoteMap.PadNote).To(HaveLen(128))
+			notes := map[int]bool{}
+			for i, padNote := range padNoteMap.PadNote {
+				Expect(padNote.Number).To(Equal(fmt.Sprintf("%d", i+1)))
+				notes[padNote.Note] = true
+			}
+			Expect(notes).To(HaveLen(128))
+			Expect(padNoteMap.PadNote[0].Note).To(Equal(36))
+			Expect(padNoteMap.PadNote[1].Note).To(Equal(38))
+			Expect(padNoteMap.PadNote[2].Note).To(Equal(1))
+			Expect(padNoteMap.PadNote[3].Note).To(Equal(0))
+			Expect(padNoteMap.PadNote[4].Note).To(Equal(2))
+
+			pad, ok := padNoteMap.Pad(38)
+			Expect(ok).To(BeTrue())
+			Expect(pad).To(Equal(1))
+		})
+
+		It("should report notes no pad sends", func() {
+			padNoteMap := xpm.PadNoteMap{PadNote: []xpm.PadNote{{Number: "1", Note: 37}}}
+
+			_, ok := padNoteMap.Pad(36)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
 	Context("When comparing Drum vs Keygroup programs", func() {
 		It("should have different Type attributes", func() {
 			drumXPM := xpm.NewXPMDrum()
@@ -101,8 +145,8 @@ var _ = Describe("Drum Program XPM Generation", func() {
 			drumXPM := xpm.NewXPMDrum()
 			keygroupXPM := xpm.NewXPMKeygroup()
 
-			Expect(drumXPM.Program.KeygroupPitchBendRange).To(Equal("0.000000"))
-			Expect(keygroupXPM.Program.KeygroupPitchBendRange).NotTo(Equal("0.000000"))
+			Expect(drumXPM.Program.KeygroupPitchBendRange.String()).To(Equal("0.000000"))
+			Expect(keygroupXPM.Program.KeygroupPitchBendRange.String()).NotTo(Equal("0.000000"))
 		})
 	})
 })